@@ -0,0 +1,138 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ccdb
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Object describes an object stored in CCDB.
+// Timestamps are expressed in milliseconds since the Unix epoch.
+type Object struct {
+	ID           string            `json:"id"`           // unique identifier of the object
+	Path         string            `json:"path"`         // path under which the object is stored
+	ValidFrom    int64             `json:"validFrom"`    // beginning of the validity interval (inclusive)
+	ValidUntil   int64             `json:"validUntil"`   // end of the validity interval (exclusive)
+	Created      int64             `json:"createTime"`   // creation time
+	LastModified int64             `json:"lastModified"` // last modification time
+	MD5          string            `json:"MD5"`          // MD5 checksum of the content
+	FileName     string            `json:"fileName"`     // original file name
+	ContentType  string            `json:"contentType"`  // MIME type of the content
+	Size         int64             `json:"size"`         // size of the content, in bytes
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// Validity returns the validity interval of the object.
+func (o Object) Validity() (from, until time.Time) {
+	return Time(o.ValidFrom), Time(o.ValidUntil)
+}
+
+// IsValid returns whether the object is valid at t.
+func (o Object) IsValid(t time.Time) bool {
+	ms := Millis(t)
+	return o.ValidFrom <= ms && ms < o.ValidUntil
+}
+
+// Listing is the result of browsing a CCDB path.
+type Listing struct {
+	Objects    []Object `json:"objects"`
+	Subfolders []string `json:"subfolders"`
+}
+
+// Millis returns t as a number of milliseconds since the Unix epoch.
+func Millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// Time returns the time corresponding to ms milliseconds since the Unix epoch.
+func Time(ms int64) time.Time {
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond)).UTC()
+}
+
+// HTTP headers used to describe an object.
+const (
+	HeaderValidFrom  = "Valid-From"
+	HeaderValidUntil = "Valid-Until"
+	HeaderCreated    = "Created"
+	HeaderPath       = "Content-Location"
+	HeaderMD5        = "Content-MD5"
+	HeaderMetadata   = "X-Ccdb-Metadata" // one "key=value" header value per metadata entry
+)
+
+// SetHeader describes o in the provided HTTP header.
+func (o Object) SetHeader(h http.Header) {
+	h.Set("ETag", strconv.Quote(o.ID))
+	h.Set(HeaderPath, o.Path)
+	h.Set(HeaderValidFrom, strconv.FormatInt(o.ValidFrom, 10))
+	h.Set(HeaderValidUntil, strconv.FormatInt(o.ValidUntil, 10))
+	h.Set(HeaderCreated, strconv.FormatInt(o.Created, 10))
+	h.Set("Last-Modified", Time(o.LastModified).Format(http.TimeFormat))
+	if o.MD5 != "" {
+		h.Set(HeaderMD5, o.MD5)
+	}
+	if o.FileName != "" {
+		h.Set("Content-Disposition", fmt.Sprintf("inline;filename=%q", o.FileName))
+	}
+	if o.ContentType != "" {
+		h.Set("Content-Type", o.ContentType)
+	}
+	h.Set("Content-Length", strconv.FormatInt(o.Size, 10))
+	for k, v := range o.Metadata {
+		h.Add(HeaderMetadata, k+"="+v)
+	}
+}
+
+// objectFrom decodes an object description from the provided HTTP header.
+func objectFrom(h http.Header) Object {
+	o := Object{
+		ID:          strings.Trim(h.Get("ETag"), `"`),
+		Path:        h.Get(HeaderPath),
+		ValidFrom:   parseInt(h.Get(HeaderValidFrom)),
+		ValidUntil:  parseInt(h.Get(HeaderValidUntil)),
+		Created:     parseInt(h.Get(HeaderCreated)),
+		MD5:         h.Get(HeaderMD5),
+		ContentType: h.Get("Content-Type"),
+		Size:        parseInt(h.Get("Content-Length")),
+	}
+
+	if t, err := http.ParseTime(h.Get("Last-Modified")); err == nil {
+		o.LastModified = Millis(t)
+	}
+
+	if v := h.Get("Content-Disposition"); v != "" {
+		if i := strings.Index(v, "filename="); i >= 0 {
+			name := v[i+len("filename="):]
+			if s, err := strconv.Unquote(name); err == nil {
+				name = s
+			}
+			o.FileName = name
+		}
+	}
+
+	for _, kv := range h[http.CanonicalHeaderKey(HeaderMetadata)] {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			continue
+		}
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]string)
+		}
+		o.Metadata[kv[:i]] = kv[i+1:]
+	}
+
+	return o
+}
+
+func parseInt(s string) int64 {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
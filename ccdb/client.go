@@ -0,0 +1,330 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ccdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Error is returned when the CCDB server replies with a 4xx or 5xx status.
+type Error struct {
+	Method string // HTTP method of the request
+	URL    string // URL of the request
+	Code   int    // HTTP status code of the response
+	Status string // HTTP status of the response
+	Msg    string // (possibly truncated) body of the response
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("ccdb: %s %s: %s", e.Method, e.URL, e.Status)
+	if e.Msg != "" {
+		msg += ": " + e.Msg
+	}
+	return msg
+}
+
+// IsNotFound returns whether err is an Error with a 404 status.
+func IsNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*Error)
+	return ok && e.Code == http.StatusNotFound
+}
+
+// Client is a client for a CCDB server.
+type Client struct {
+	addr string
+	hc   *http.Client
+}
+
+// NewClient creates a new client for the CCDB server at addr,
+// e.g. "http://ccdb-test.cern.ch:8080".
+// If hc is nil, http.DefaultClient is used.
+//
+// Redirections are followed, except towards non-HTTP locations
+// (e.g. "alien://") which are reported as errors.
+func NewClient(addr string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	cli := *hc
+	cli.CheckRedirect = checkRedirect
+	return &Client{
+		addr: strings.TrimRight(addr, "/"),
+		hc:   &cli,
+	}
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.Errorf("ccdb: stopped after 10 redirects")
+	}
+	switch req.URL.Scheme {
+	case "http", "https":
+		return nil
+	}
+	return errors.Errorf("ccdb: unsupported redirection to %q", req.URL)
+}
+
+// Upload uploads the content of r, with the provided file name and metadata,
+// as a new object stored under path and valid for the [from, until) interval.
+func (cli *Client) Upload(path string, from, until time.Time, meta map[string]string, fname string, r io.Reader) (Object, error) {
+	if !until.After(from) {
+		return Object{}, errors.Errorf("ccdb: invalid validity interval [%v, %v)", from, until)
+	}
+
+	elems := []string{strconv.FormatInt(Millis(from), 10), strconv.FormatInt(Millis(until), 10)}
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		elems = append(elems, k+"="+meta[k])
+	}
+
+	body := new(bytes.Buffer)
+	mpw := multipart.NewWriter(body)
+	w, err := mpw.CreateFormFile("blob", filepath.Base(fname))
+	if err != nil {
+		return Object{}, errors.Wrapf(err, "ccdb: could not create multipart file")
+	}
+	_, err = io.Copy(w, r)
+	if err != nil {
+		return Object{}, errors.Wrapf(err, "ccdb: could not copy file to request body")
+	}
+	err = mpw.Close()
+	if err != nil {
+		return Object{}, errors.Wrapf(err, "ccdb: could not close multipart body")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cli.url(path, elems...), body)
+	if err != nil {
+		return Object{}, errors.Wrapf(err, "ccdb: could not create request")
+	}
+	req.Header.Set("Content-Type", mpw.FormDataContentType())
+
+	resp, err := cli.do(req)
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+
+	o := Object{
+		Path:       path,
+		ValidFrom:  Millis(from),
+		ValidUntil: Millis(until),
+		FileName:   filepath.Base(fname),
+		Metadata:   meta,
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		err = json.NewDecoder(resp.Body).Decode(&o)
+		if err != nil {
+			return o, errors.Wrapf(err, "ccdb: could not decode upload response")
+		}
+		return o, nil
+	}
+	o.ID = strings.Trim(resp.Header.Get("ETag"), `"`)
+	return o, nil
+}
+
+// Get retrieves the newest object stored under path and valid at t.
+func (cli *Client) Get(path string, t time.Time) (Object, []byte, error) {
+	return cli.get(cli.url(path, strconv.FormatInt(Millis(t), 10)))
+}
+
+// Latest retrieves the newest object stored under path.
+func (cli *Client) Latest(path string) (Object, []byte, error) {
+	return cli.get(cli.url("latest/" + strings.TrimLeft(path, "/")))
+}
+
+func (cli *Client) get(url string) (Object, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return Object{}, nil, errors.Wrapf(err, "ccdb: could not create request")
+	}
+
+	resp, err := cli.do(req)
+	if err != nil {
+		return Object{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Object{}, nil, errors.Wrapf(err, "ccdb: could not read response body")
+	}
+
+	o := objectFrom(resp.Header)
+	o.Size = int64(len(raw))
+	return o, raw, nil
+}
+
+// Head retrieves the description of the newest object stored under path and valid at t,
+// without its content.
+func (cli *Client) Head(path string, t time.Time) (Object, error) {
+	return cli.head(cli.url(path, strconv.FormatInt(Millis(t), 10)))
+}
+
+func (cli *Client) head(url string) (Object, error) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return Object{}, errors.Wrapf(err, "ccdb: could not create request")
+	}
+
+	resp, err := cli.do(req)
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+
+	return objectFrom(resp.Header), nil
+}
+
+// Browse lists the objects stored under path and its sub-folders.
+func (cli *Client) Browse(path string) (Listing, error) {
+	var list Listing
+
+	req, err := http.NewRequest(http.MethodGet, cli.url("browse/"+strings.TrimLeft(path, "/")), nil)
+	if err != nil {
+		return list, errors.Wrapf(err, "ccdb: could not create request")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := cli.do(req)
+	if err != nil {
+		return list, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	if err != nil {
+		return list, errors.Wrapf(err, "ccdb: could not decode listing of %q", path)
+	}
+	return list, nil
+}
+
+// Invalidate ends the validity of an object stored under path now.
+// The object is either the one with the provided id or, if id is empty,
+// the newest object valid at t.
+//
+// Invalidate is equivalent to InvalidateAt(path, t, id, time.Now()).
+func (cli *Client) Invalidate(path string, t time.Time, id string) error {
+	return cli.InvalidateAt(path, t, id, time.Now())
+}
+
+// InvalidateAt ends the validity of an object stored under path at end.
+// The object is either the one with the provided id or, if id is empty,
+// the newest object valid at t.
+//
+// The validity of the object is never extended: nothing is done if the
+// object is already not valid at end.
+// InvalidateAt returns an error if end is not after the beginning of the
+// validity of the object, as such an object should be deleted instead.
+func (cli *Client) InvalidateAt(path string, t time.Time, id string, end time.Time) error {
+	elems := []string{strconv.FormatInt(Millis(t), 10)}
+	if id != "" {
+		elems = append(elems, id)
+	}
+	u := cli.url(path, elems...)
+
+	o, err := cli.head(u)
+	if err != nil {
+		return err
+	}
+
+	until := Millis(end)
+	switch {
+	case until >= o.ValidUntil:
+		return nil
+	case until <= o.ValidFrom:
+		return errors.Errorf(
+			"ccdb: could not invalidate object %q at %d: its validity starts at %d (delete it instead)",
+			o.ID, until, o.ValidFrom,
+		)
+	}
+
+	v := url.Values{HeaderValidUntil: {strconv.FormatInt(until, 10)}}
+	req, err := http.NewRequest(http.MethodPut, u+"?"+v.Encode(), nil)
+	if err != nil {
+		return errors.Wrapf(err, "ccdb: could not create request")
+	}
+
+	resp, err := cli.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Delete deletes an object stored under path.
+// The object is either the one with the provided id or, if id is empty,
+// the newest object valid at t.
+func (cli *Client) Delete(path string, t time.Time, id string) error {
+	elems := []string{strconv.FormatInt(Millis(t), 10)}
+	if id != "" {
+		elems = append(elems, id)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, cli.url(path, elems...), nil)
+	if err != nil {
+		return errors.Wrapf(err, "ccdb: could not create request")
+	}
+
+	resp, err := cli.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// url builds the URL for path, followed by the provided elements.
+func (cli *Client) url(path string, elems ...string) string {
+	o := new(strings.Builder)
+	o.WriteString(cli.addr)
+	for _, v := range strings.Split(strings.Trim(path, "/"), "/") {
+		o.WriteString("/")
+		o.WriteString(url.PathEscape(v))
+	}
+	for _, v := range elems {
+		o.WriteString("/")
+		o.WriteString(url.PathEscape(v))
+	}
+	return o.String()
+}
+
+// do sends the request and turns 4xx and 5xx responses into errors.
+func (cli *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := cli.hc.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ccdb: could not send %s request to %s", req.Method, req.URL)
+	}
+
+	if resp.StatusCode < 400 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, &Error{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Code:   resp.StatusCode,
+		Status: resp.Status,
+		Msg:    strings.TrimSpace(string(msg)),
+	}
+}
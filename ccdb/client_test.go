@@ -0,0 +1,362 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ccdb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) (*Client, func()) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	return NewClient(srv.URL, srv.Client()), srv.Close
+}
+
+func TestUpload(t *testing.T) {
+	var (
+		from  = Time(1500000000000)
+		until = Time(1500003600000)
+	)
+	cli, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("invalid method: got=%q, want=%q", r.Method, http.MethodPost)
+		}
+		want := "/MUON/Calib/Gains/1500000000000/1500003600000/a=b/key=c%2Fd"
+		if got := r.URL.EscapedPath(); got != want {
+			t.Errorf("invalid URL path:\ngot= %q\nwant=%q", got, want)
+		}
+		f, hdr, err := r.FormFile("blob")
+		if err != nil {
+			t.Fatalf("could not read blob: %+v", err)
+		}
+		defer f.Close()
+		raw, _ := ioutil.ReadAll(f)
+		if got, want := string(raw), "content"; got != want {
+			t.Errorf("invalid content: got=%q, want=%q", got, want)
+		}
+		if got, want := hdr.Filename, "Run1_10_v1_s0.root"; got != want {
+			t.Errorf("invalid file name: got=%q, want=%q", got, want)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Object{
+			ID:         "id-1",
+			Path:       "MUON/Calib/Gains",
+			ValidFrom:  Millis(from),
+			ValidUntil: Millis(until),
+			FileName:   hdr.Filename,
+		})
+	})
+	defer done()
+
+	meta := map[string]string{"key": "c/d", "a": "b"}
+	o, err := cli.Upload("/MUON/Calib/Gains", from, until, meta, "dir/Run1_10_v1_s0.root", strings.NewReader("content"))
+	if err != nil {
+		t.Fatalf("could not upload: %+v", err)
+	}
+	want := Object{
+		ID:         "id-1",
+		Path:       "MUON/Calib/Gains",
+		ValidFrom:  1500000000000,
+		ValidUntil: 1500003600000,
+		FileName:   "Run1_10_v1_s0.root",
+		Metadata:   meta,
+	}
+	if !reflect.DeepEqual(o, want) {
+		t.Fatalf("invalid object:\ngot= %#v\nwant=%#v", o, want)
+	}
+
+	_, err = cli.Upload("MUON/Calib/Gains", until, from, nil, "f.root", strings.NewReader(""))
+	if err == nil {
+		t.Fatalf("expected an error for an inverted validity interval")
+	}
+}
+
+// testObject is the object served by objectHandler.
+var testObject = Object{
+	ID:           "id-1",
+	Path:         "MUON/Calib/Gains",
+	ValidFrom:    1500000000000,
+	ValidUntil:   1500003600000,
+	Created:      1500000000001,
+	LastModified: 1500000001000,
+	MD5:          "9a0364b9e99bb480dd25e1f0284c8555",
+	FileName:     "Run1_10_v1_s0.root",
+	ContentType:  "application/octet-stream",
+	Size:         7,
+	Metadata:     map[string]string{"a": "b"},
+}
+
+// objectHandler serves testObject, redirecting GET /<path>/<timestamp>
+// requests to /download/<id>, as CCDB does.
+func objectHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download/id-1", "/latest/MUON/Calib/Gains":
+			testObject.SetHeader(w.Header())
+			w.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodGet {
+				w.Write([]byte("content"))
+			}
+		case "/MUON/Calib/Gains/1500000000500":
+			if r.Method == http.MethodHead {
+				testObject.SetHeader(w.Header())
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			http.Redirect(w, r, "/download/id-1", http.StatusSeeOther)
+		default:
+			http.Error(w, "no such object", http.StatusNotFound)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	cli, done := newTestClient(t, objectHandler())
+	defer done()
+
+	for _, tc := range []struct {
+		name string
+		get  func() (Object, []byte, error)
+	}{
+		{"get", func() (Object, []byte, error) { return cli.Get("MUON/Calib/Gains", Time(1500000000500)) }},
+		{"latest", func() (Object, []byte, error) { return cli.Latest("/MUON/Calib/Gains") }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, raw, err := tc.get()
+			if err != nil {
+				t.Fatalf("could not get object: %+v", err)
+			}
+			if got, want := string(raw), "content"; got != want {
+				t.Fatalf("invalid content: got=%q, want=%q", got, want)
+			}
+			if !reflect.DeepEqual(o, testObject) {
+				t.Fatalf("invalid object:\ngot= %#v\nwant=%#v", o, testObject)
+			}
+		})
+	}
+}
+
+func TestHead(t *testing.T) {
+	cli, done := newTestClient(t, objectHandler())
+	defer done()
+
+	o, err := cli.Head("MUON/Calib/Gains", Time(1500000000500))
+	if err != nil {
+		t.Fatalf("could not get object description: %+v", err)
+	}
+	if !reflect.DeepEqual(o, testObject) {
+		t.Fatalf("invalid object:\ngot= %#v\nwant=%#v", o, testObject)
+	}
+
+	_, err = cli.Head("MUON/Calib/Gains", Time(1600000000000))
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not-found error, got %+v", err)
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	want := Listing{
+		Objects:    []Object{testObject},
+		Subfolders: []string{"MUON/Calib/Gains"},
+	}
+	cli, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Path, "/browse/MUON/Calib"; got != want {
+			t.Errorf("invalid URL path: got=%q, want=%q", got, want)
+		}
+		if got, want := r.Header.Get("Accept"), "application/json"; got != want {
+			t.Errorf("invalid Accept header: got=%q, want=%q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(want)
+	})
+	defer done()
+
+	list, err := cli.Browse("/MUON/Calib")
+	if err != nil {
+		t.Fatalf("could not browse: %+v", err)
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Fatalf("invalid listing:\ngot= %#v\nwant=%#v", list, want)
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	cli, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "alien:///alice/data/CCDB/id-1", http.StatusSeeOther)
+	})
+	defer done()
+
+	_, _, err := cli.Get("MUON/Calib/Gains", Time(1500000000500))
+	if err == nil || !strings.Contains(err.Error(), "unsupported redirection") {
+		t.Fatalf("expected an unsupported redirection error, got %v", err)
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	const (
+		from  = 1500000000000
+		until = 1500003600000
+	)
+	for _, tc := range []struct {
+		name  string
+		id    string
+		end   int64
+		want  string // expected Valid-Until value, if any
+		fails bool
+	}{
+		{name: "end", end: from + 1000, want: "1500000001000"},
+		{name: "end-id", id: "id-1", end: from + 1000, want: "1500000001000"},
+		{name: "after-until", end: until + 1000},
+		{name: "at-until", end: until},
+		{name: "at-from", end: from, fails: true},
+		{name: "before-from", end: from - 1000, fails: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			wantPath := "/MUON/Calib/Gains/1500000000500"
+			if tc.id != "" {
+				wantPath += "/" + tc.id
+			}
+			var got string
+			cli, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != wantPath {
+					t.Errorf("invalid URL path: got=%q, want=%q", r.URL.Path, wantPath)
+				}
+				o := testObject
+				o.ValidFrom, o.ValidUntil = from, until
+				switch r.Method {
+				case http.MethodHead:
+					o.SetHeader(w.Header())
+				case http.MethodPut:
+					got = r.URL.Query().Get(HeaderValidUntil)
+				default:
+					t.Errorf("invalid method %q", r.Method)
+				}
+			})
+			defer done()
+
+			err := cli.InvalidateAt("MUON/Calib/Gains", Time(1500000000500), tc.id, Time(tc.end))
+			switch {
+			case tc.fails && err == nil:
+				t.Fatalf("expected an error")
+			case !tc.fails && err != nil:
+				t.Fatalf("could not invalidate: %+v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("invalid Valid-Until: got=%q, want=%q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInvalidateNow(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		from, until time.Time
+		put         bool
+		fails       bool
+	}{
+		{name: "current", from: time.Now().Add(-time.Hour), until: time.Now().Add(time.Hour), put: true},
+		{name: "past", from: time.Now().Add(-2 * time.Hour), until: time.Now().Add(-time.Hour)},
+		{name: "future", from: time.Now().Add(time.Hour), until: time.Now().Add(2 * time.Hour), fails: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var put int64
+			cli, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				o := testObject
+				o.ValidFrom, o.ValidUntil = Millis(tc.from), Millis(tc.until)
+				switch r.Method {
+				case http.MethodHead:
+					o.SetHeader(w.Header())
+				case http.MethodPut:
+					put, _ = strconv.ParseInt(r.URL.Query().Get(HeaderValidUntil), 10, 64)
+				}
+			})
+			defer done()
+
+			err := cli.Invalidate("MUON/Calib/Gains", tc.from, "")
+			switch {
+			case tc.fails && err == nil:
+				t.Fatalf("expected an error")
+			case !tc.fails && err != nil:
+				t.Fatalf("could not invalidate: %+v", err)
+			}
+			switch {
+			case !tc.put && put != 0:
+				t.Fatalf("unexpected update of Valid-Until to %d", put)
+			case tc.put && (put <= Millis(tc.from) || put >= Millis(tc.until)):
+				t.Fatalf("invalid Valid-Until %d, want in (%d, %d)", put, Millis(tc.from), Millis(tc.until))
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	deleted := false
+	cli, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/MUON/Calib/Gains/1500000000500/id-1" {
+			http.Error(w, "no such object", http.StatusNotFound)
+			return
+		}
+		deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer done()
+
+	err := cli.Delete("MUON/Calib/Gains", Time(1500000000500), "id-1")
+	if err != nil {
+		t.Fatalf("could not delete: %+v", err)
+	}
+	if !deleted {
+		t.Fatalf("object not deleted")
+	}
+
+	err = cli.Delete("MUON/Calib/Gains", Time(1500000000500), "id-2")
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not-found error, got %+v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		code     int
+		notFound bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, true},
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+	} {
+		t.Run(strconv.Itoa(tc.code), func(t *testing.T) {
+			cli, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "boom", tc.code)
+			})
+			defer done()
+
+			_, _, err := cli.Latest("MUON/Calib/Gains")
+			e, ok := errors.Cause(err).(*Error)
+			if !ok {
+				t.Fatalf("invalid error type %T: %v", err, err)
+			}
+			if e.Code != tc.code || e.Method != http.MethodGet || e.Msg != "boom" {
+				t.Fatalf("invalid error: %#v", e)
+			}
+			if got := IsNotFound(err); got != tc.notFound {
+				t.Fatalf("IsNotFound: got=%v, want=%v", got, tc.notFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ccdb implements a client for the REST API of the ALICE CCDB
+// (Condition and Calibration DataBase).
+//
+// Objects are stored under a path, with a validity interval expressed as
+// a pair of timestamps in milliseconds since the Unix epoch.
+// The REST API endpoints used by this package are:
+//   - POST   /<path>/<from>/<until>[/<key>=<value>...]: upload an object (multipart "blob" file),
+//   - GET    /<path>/<timestamp>: retrieve the newest object valid at timestamp,
+//   - GET    /latest/<path>: retrieve the newest object stored under path,
+//   - GET    /browse/<path>: list objects stored under path (JSON),
+//   - HEAD   /<path>/<timestamp>: retrieve the description of an object,
+//   - HEAD   /<path>/<timestamp>/<id>: retrieve the description of an object, by id,
+//   - PUT    /<path>/<timestamp>/<id>?Valid-Until=<until>: invalidate an object,
+//   - DELETE /<path>/<timestamp>/<id>: delete an object.
+package ccdb // import "github.com/alice-go/aligo/ccdb"
@@ -0,0 +1,44 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ccdb-server is a stand-in for a CCDB server, storing objects
+// and their metadata in a local directory.
+//
+// It implements the subset of the CCDB REST API used by the ccdb package.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	log.SetPrefix("ccdb-server: ")
+	log.SetFlags(0)
+
+	var (
+		addr = flag.String("addr", ":6464", "address to listen on")
+		dir  = flag.String("dir", "ccdb-data", "directory where objects are stored")
+	)
+
+	flag.Parse()
+
+	err := os.MkdirAll(*dir, 0755)
+	if err != nil {
+		log.Fatalf("could not create storage directory: %+v", err)
+	}
+
+	srv, err := newServer(*dir)
+	if err != nil {
+		log.Fatalf("could not create server: %+v", err)
+	}
+
+	log.Printf("serving %q on %s...", *dir, *addr)
+	err = http.ListenAndServe(*addr, srv)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,570 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alice-go/aligo/ccdb"
+	"github.com/pkg/errors"
+)
+
+// defaultValidity is the validity of objects uploaded without an end of validity.
+const defaultValidity = 24 * time.Hour
+
+// server serves objects stored under dir as:
+//
+//	<dir>/<path>/<id>      the content of the object,
+//	<dir>/<path>/<id>.json the description of the object.
+type server struct {
+	dir string
+
+	mu   sync.RWMutex
+	objs map[string]ccdb.Object // objects, by id
+}
+
+func newServer(dir string) (*server, error) {
+	srv := &server{
+		dir:  dir,
+		objs: make(map[string]ccdb.Object),
+	}
+
+	err := filepath.Walk(dir, func(fname string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(fname) != ".json" {
+			return nil
+		}
+		raw, err := ioutil.ReadFile(fname)
+		if err != nil {
+			return err
+		}
+		var o ccdb.Object
+		err = json.Unmarshal(raw, &o)
+		if err != nil {
+			return errors.Wrapf(err, "could not decode %q", fname)
+		}
+		srv.objs[o.ID] = o
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load objects")
+	}
+
+	return srv, nil
+}
+
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var err error
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/browse/"), r.URL.Path == "/browse":
+			err = srv.browse(w, r)
+		case strings.HasPrefix(r.URL.Path, "/latest/"):
+			err = srv.latest(w, r)
+		case strings.HasPrefix(r.URL.Path, "/download/"):
+			err = srv.download(w, r)
+		default:
+			err = srv.get(w, r)
+		}
+	case http.MethodPost:
+		err = srv.upload(w, r)
+	case http.MethodPut:
+		err = srv.update(w, r)
+	case http.MethodDelete:
+		err = srv.delete(w, r)
+	default:
+		err = httpError{http.StatusMethodNotAllowed, errors.Errorf("invalid method %q", r.Method)}
+	}
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		if e, ok := err.(httpError); ok {
+			code = e.code
+		}
+		log.Printf("%s %s: %v", r.Method, r.URL, err)
+		http.Error(w, err.Error(), code)
+	}
+}
+
+type httpError struct {
+	code int
+	err  error
+}
+
+func (e httpError) Error() string { return e.err.Error() }
+
+func notFound(format string, args ...interface{}) error {
+	return httpError{http.StatusNotFound, errors.Errorf(format, args...)}
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return httpError{http.StatusBadRequest, errors.Errorf(format, args...)}
+}
+
+// upload handles POST /<path>/<from>[/<until>][/<key>=<value>...].
+func (srv *server) upload(w http.ResponseWriter, r *http.Request) error {
+	elems, err := splitPath(r.URL.EscapedPath())
+	if err != nil {
+		return err
+	}
+
+	meta := make(map[string]string)
+	for len(elems) > 0 && strings.Contains(elems[len(elems)-1], "=") {
+		kv := elems[len(elems)-1]
+		i := strings.Index(kv, "=")
+		meta[kv[:i]] = kv[i+1:]
+		elems = elems[:len(elems)-1]
+	}
+
+	var times []int64
+	for len(elems) > 1 && len(times) < 2 {
+		v, err := strconv.ParseInt(elems[len(elems)-1], 10, 64)
+		if err != nil {
+			break
+		}
+		times = append([]int64{v}, times...)
+		elems = elems[:len(elems)-1]
+	}
+
+	if len(elems) == 0 || len(times) == 0 {
+		return badRequest("invalid upload URL %q", r.URL.Path)
+	}
+	for _, v := range elems {
+		if strings.Contains(v, "/") {
+			return badRequest("invalid path element %q", v)
+		}
+	}
+	if len(times) == 1 {
+		times = append(times, times[0]+int64(defaultValidity/time.Millisecond))
+	}
+	if times[1] <= times[0] {
+		return badRequest("invalid validity interval [%d, %d)", times[0], times[1])
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return badRequest("could not read multipart request: %v", err)
+	}
+
+	var part io.Reader
+	var fname string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return badRequest("could not read multipart request: %v", err)
+		}
+		if p.FileName() == "" {
+			continue
+		}
+		part = p
+		fname = p.FileName()
+		break
+	}
+	if part == nil {
+		return badRequest("no file in upload request")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	now := ccdb.Millis(time.Now())
+	o := ccdb.Object{
+		ID:           id,
+		Path:         path.Join(elems...),
+		ValidFrom:    times[0],
+		ValidUntil:   times[1],
+		Created:      now,
+		LastModified: now,
+		FileName:     fname,
+		ContentType:  "application/octet-stream",
+	}
+	if len(meta) > 0 {
+		o.Metadata = meta
+	}
+
+	err = os.MkdirAll(filepath.Dir(srv.fname(o)), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(srv.fname(o))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), part)
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	o.Size = n
+	o.MD5 = hex.EncodeToString(hash.Sum(nil))
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	err = srv.save(o)
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	w.Header().Set("Location", "/download/"+o.ID)
+	w.Header().Set("ETag", strconv.Quote(o.ID))
+	return writeJSON(w, http.StatusCreated, o)
+}
+
+// get handles GET and HEAD /<path>/<timestamp>[/<id>].
+// GET requests are redirected to /download/<id>.
+func (srv *server) get(w http.ResponseWriter, r *http.Request) error {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	o, err := srv.find(r.URL.EscapedPath())
+	if err != nil {
+		return err
+	}
+
+	if r.Method == http.MethodHead {
+		o.SetHeader(w.Header())
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	http.Redirect(w, r, "/download/"+o.ID, http.StatusSeeOther)
+	return nil
+}
+
+// latest handles GET and HEAD /latest/<path>.
+func (srv *server) latest(w http.ResponseWriter, r *http.Request) error {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	elems, err := splitPath(strings.TrimPrefix(r.URL.EscapedPath(), "/latest"))
+	if err != nil {
+		return err
+	}
+	p := path.Join(elems...)
+
+	var (
+		o     ccdb.Object
+		found = false
+	)
+	for _, v := range srv.objs {
+		if v.Path != p {
+			continue
+		}
+		if !found || newer(v, o) {
+			o = v
+			found = true
+		}
+	}
+	if !found {
+		return notFound("no object under %q", p)
+	}
+
+	return srv.serve(w, r, o)
+}
+
+// download handles GET and HEAD /download/<id>.
+func (srv *server) download(w http.ResponseWriter, r *http.Request) error {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	id := strings.TrimPrefix(r.URL.Path, "/download/")
+	o, ok := srv.objs[id]
+	if !ok {
+		return notFound("no object with id %q", id)
+	}
+
+	return srv.serve(w, r, o)
+}
+
+func (srv *server) serve(w http.ResponseWriter, r *http.Request, o ccdb.Object) error {
+	o.SetHeader(w.Header())
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	f, err := os.Open(srv.fname(o))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, f)
+	if err != nil {
+		log.Printf("could not send object %q: %+v", o.ID, err)
+	}
+	return nil
+}
+
+// browse handles GET /browse/<path>, listing the objects stored under path
+// or its sub-folders. A trailing "*" is ignored.
+func (srv *server) browse(w http.ResponseWriter, r *http.Request) error {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	elems, err := splitPath(strings.TrimPrefix(r.URL.EscapedPath(), "/browse"))
+	if err != nil {
+		return err
+	}
+	if n := len(elems); n > 0 && elems[n-1] == "*" {
+		elems = elems[:n-1]
+	}
+	p := path.Join(elems...)
+
+	list := ccdb.Listing{
+		Objects:    []ccdb.Object{},
+		Subfolders: []string{},
+	}
+	subs := make(map[string]struct{})
+	for _, o := range srv.objs {
+		switch {
+		case p == "", o.Path == p, strings.HasPrefix(o.Path, p+"/"):
+		default:
+			continue
+		}
+		list.Objects = append(list.Objects, o)
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(o.Path, p), "/")
+		if rel == "" {
+			continue
+		}
+		if i := strings.Index(rel, "/"); i >= 0 {
+			rel = rel[:i]
+		}
+		subs[path.Join(p, rel)] = struct{}{}
+	}
+	for k := range subs {
+		list.Subfolders = append(list.Subfolders, k)
+	}
+
+	sort.Slice(list.Objects, func(i, j int) bool {
+		oi, oj := list.Objects[i], list.Objects[j]
+		if oi.Path != oj.Path {
+			return oi.Path < oj.Path
+		}
+		return newer(oi, oj)
+	})
+	sort.Strings(list.Subfolders)
+
+	return writeJSON(w, http.StatusOK, list)
+}
+
+// update handles PUT /<path>/<timestamp>[/<id>]?Valid-Until=<until>[&<key>=<value>...].
+func (srv *server) update(w http.ResponseWriter, r *http.Request) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	o, err := srv.find(r.URL.EscapedPath())
+	if err != nil {
+		return err
+	}
+
+	for k, vs := range r.URL.Query() {
+		v := vs[len(vs)-1]
+		switch k {
+		case ccdb.HeaderValidUntil:
+			until, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return badRequest("invalid %s value %q", k, v)
+			}
+			if until <= o.ValidFrom {
+				return badRequest("invalid validity interval [%d, %d)", o.ValidFrom, until)
+			}
+			o.ValidUntil = until
+		default:
+			if o.Metadata == nil {
+				o.Metadata = make(map[string]string)
+			}
+			o.Metadata[k] = v
+		}
+	}
+	o.LastModified = ccdb.Millis(time.Now())
+
+	err = srv.save(o)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, o)
+}
+
+// delete handles DELETE /<path>/<timestamp>[/<id>].
+func (srv *server) delete(w http.ResponseWriter, r *http.Request) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	o, err := srv.find(r.URL.EscapedPath())
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(srv.fname(o) + ".json")
+	if err != nil {
+		return err
+	}
+	delete(srv.objs, o.ID)
+
+	err = os.Remove(srv.fname(o))
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// find returns the object designated by a /<path>/<timestamp>[/<id>] escaped URL path:
+// either the object with the provided id, or the newest object valid at timestamp.
+func (srv *server) find(urlpath string) (ccdb.Object, error) {
+	elems, err := splitPath(urlpath)
+	if err != nil {
+		return ccdb.Object{}, err
+	}
+
+	var id string
+	if n := len(elems); n > 2 {
+		if _, err := strconv.ParseInt(elems[n-2], 10, 64); err == nil {
+			id = elems[n-1]
+			elems = elems[:n-1]
+		}
+	}
+
+	n := len(elems)
+	if n < 2 {
+		return ccdb.Object{}, badRequest("invalid URL path %q", urlpath)
+	}
+	ts, err := strconv.ParseInt(elems[n-1], 10, 64)
+	if err != nil {
+		return ccdb.Object{}, badRequest("invalid timestamp %q", elems[n-1])
+	}
+	p := path.Join(elems[:n-1]...)
+
+	if id != "" {
+		o, ok := srv.objs[id]
+		if !ok || o.Path != p {
+			return o, notFound("no object with id %q under %q", id, p)
+		}
+		return o, nil
+	}
+
+	var (
+		o     ccdb.Object
+		found = false
+	)
+	for _, v := range srv.objs {
+		if v.Path != p || ts < v.ValidFrom || v.ValidUntil <= ts {
+			continue
+		}
+		if !found || newer(v, o) {
+			o = v
+			found = true
+		}
+	}
+	if !found {
+		return o, notFound("no object under %q valid at %d", p, ts)
+	}
+	return o, nil
+}
+
+// save writes the description of o to disk and registers it.
+func (srv *server) save(o ccdb.Object) error {
+	raw, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(srv.fname(o)+".json", raw, 0644)
+	if err != nil {
+		return err
+	}
+	srv.objs[o.ID] = o
+	return nil
+}
+
+func (srv *server) fname(o ccdb.Object) string {
+	return filepath.Join(srv.dir, filepath.FromSlash(o.Path), o.ID)
+}
+
+// newer returns whether a was created after b.
+func newer(a, b ccdb.Object) bool {
+	if a.Created != b.Created {
+		return a.Created > b.Created
+	}
+	return a.ID > b.ID
+}
+
+// splitPath splits an escaped URL path into its unescaped elements.
+// Elements are split before unescaping, so they may contain escaped slashes
+// (e.g. metadata values).
+func splitPath(p string) ([]string, error) {
+	var elems []string
+	for _, raw := range strings.Split(p, "/") {
+		v, err := url.PathUnescape(raw)
+		if err != nil {
+			return nil, badRequest("invalid URL path element %q", raw)
+		}
+		if v == "" || v == "." || v == ".." {
+			continue
+		}
+		elems = append(elems, v)
+	}
+	return elems, nil
+}
+
+func newID() (string, error) {
+	var buf [16]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		return "", errors.Wrapf(err, "could not generate object id")
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:]), nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, err = w.Write(raw)
+	return err
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alice-go/aligo/ccdb"
+)
+
+func newTestServer(t *testing.T) (*ccdb.Client, string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ccdb-server-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := newServer(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("could not create server: %+v", err)
+	}
+	hsrv := httptest.NewServer(srv)
+	return ccdb.NewClient(hsrv.URL, hsrv.Client()), hsrv.URL, func() {
+		hsrv.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestServer(t *testing.T) {
+	cli, _, done := newTestServer(t)
+	defer done()
+
+	var (
+		from  = ccdb.Time(1500000000000)
+		until = ccdb.Time(1500003600000)
+		meta  = map[string]string{"key": "c/d", "a b": "e%f"}
+	)
+	o, err := cli.Upload("MUON/Calib/Gains", from, until, meta, "Run1_10_v1_s0.root", strings.NewReader("content"))
+	if err != nil {
+		t.Fatalf("could not upload: %+v", err)
+	}
+	if !reflect.DeepEqual(o.Metadata, meta) {
+		t.Fatalf("invalid metadata:\ngot= %v\nwant=%v", o.Metadata, meta)
+	}
+
+	got, raw, err := cli.Get("MUON/Calib/Gains", ccdb.Time(1500000000500))
+	if err != nil {
+		t.Fatalf("could not get object: %+v", err)
+	}
+	if string(raw) != "content" || got.ID != o.ID || !reflect.DeepEqual(got.Metadata, meta) {
+		t.Fatalf("invalid object %#v, content %q", got, raw)
+	}
+
+	err = cli.InvalidateAt("MUON/Calib/Gains", from, o.ID, ccdb.Time(1500000001000))
+	if err != nil {
+		t.Fatalf("could not invalidate: %+v", err)
+	}
+	got, err = cli.Head("MUON/Calib/Gains", from)
+	if err != nil {
+		t.Fatalf("could not get object description: %+v", err)
+	}
+	if got.ValidUntil != 1500000001000 {
+		t.Fatalf("invalid end of validity: got=%d, want=%d", got.ValidUntil, 1500000001000)
+	}
+
+	err = cli.Delete("MUON/Calib/Gains", from, o.ID)
+	if err != nil {
+		t.Fatalf("could not delete: %+v", err)
+	}
+	_, err = cli.Head("MUON/Calib/Gains", from)
+	if !ccdb.IsNotFound(err) {
+		t.Fatalf("expected a not-found error, got %+v", err)
+	}
+}
+
+func TestServerInvalidRequests(t *testing.T) {
+	cli, addr, done := newTestServer(t)
+	defer done()
+
+	from, until := ccdb.Time(1500000000000), ccdb.Time(1500003600000)
+	o, err := cli.Upload("MUON/Calib/Gains", from, until, nil, "f.root", strings.NewReader("content"))
+	if err != nil {
+		t.Fatalf("could not upload: %+v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		url    string
+	}{
+		{"update-inverted", http.MethodPut, "/MUON/Calib/Gains/1500000000000/" + o.ID + "?Valid-Until=1499999999000"},
+		{"update-empty", http.MethodPut, "/MUON/Calib/Gains/1500000000000/" + o.ID + "?Valid-Until=1500000000000"},
+		{"upload-slash", http.MethodPost, "/MUON/Calib/..%2F..%2Fetc/1500000000000/1500003600000"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, addr+tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("invalid status: got=%d, want=%d", resp.StatusCode, http.StatusBadRequest)
+			}
+		})
+	}
+
+	got, err := cli.Head("MUON/Calib/Gains", from)
+	if err != nil {
+		t.Fatalf("could not get object description: %+v", err)
+	}
+	if got.ValidUntil != ccdb.Millis(until) {
+		t.Fatalf("invalid end of validity: got=%d, want=%d", got.ValidUntil, ccdb.Millis(until))
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+
+	"github.com/alice-go/aligo/ocdb"
+)
+
+// segment is a run range for which the same file is selected.
+type segment struct {
+	first, last int64
+	id          ocdb.ID
+	hash        string
+}
+
+// group is a run range over which the selected files all have the same payload.
+type group struct {
+	path        string
+	first, last int64
+	ids         []ocdb.ID // selected files, in order of their first selected run
+	hash        string
+}
+
+func (g group) runs() ocdb.RunRange {
+	return ocdb.NewRunRange(int32(g.first), int32(g.last))
+}
+
+// selections splits the runs covered by ids, all sharing the same path, into
+// consecutive segments for which the same file is selected, following the
+// rules of AliCDBLocal: the highest version, and then subversion, wins.
+// Runs for which no file, or several equally eligible files, are valid are skipped.
+func selections(ids []ocdb.ID) []segment {
+	var bounds []int64
+	for _, id := range ids {
+		bounds = append(bounds, int64(id.Runs().First), int64(id.Runs().Last)+1)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	var segs []segment
+	for i := 0; i+1 < len(bounds); i++ {
+		if bounds[i] == bounds[i+1] {
+			continue
+		}
+		first, last := bounds[i], bounds[i+1]-1
+		query := ocdb.NewID(ids[0].Path(), ocdb.NewRunRange(int32(first), int32(first)), -1, -1)
+		id, err := ocdb.ResolveID(ids, query)
+		if err != nil {
+			continue
+		}
+		if n := len(segs); n > 0 && segs[n-1].last+1 == first && sameID(segs[n-1].id, id) {
+			segs[n-1].last = last
+			continue
+		}
+		segs = append(segs, segment{first: first, last: last, id: id})
+	}
+	return segs
+}
+
+// groups merges consecutive segments whose files have the same payload hash.
+// Only groups of at least two different files are returned.
+func groups(path string, segs []segment) []group {
+	var (
+		gs  []group
+		cur *group
+	)
+	flush := func() {
+		if cur != nil && len(cur.ids) > 1 {
+			gs = append(gs, *cur)
+		}
+		cur = nil
+	}
+
+	for _, seg := range segs {
+		if cur != nil && cur.last+1 == seg.first && cur.hash == seg.hash {
+			cur.last = seg.last
+			if !containsID(cur.ids, seg.id) {
+				cur.ids = append(cur.ids, seg.id)
+			}
+			continue
+		}
+		flush()
+		cur = &group{path: path, first: seg.first, last: seg.last, ids: []ocdb.ID{seg.id}, hash: seg.hash}
+	}
+	flush()
+	return gs
+}
+
+func containsID(ids []ocdb.ID, id ocdb.ID) bool {
+	for _, v := range ids {
+		if sameID(v, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameID(a, b ocdb.ID) bool {
+	return a.Path().Name() == b.Path().Name() && a.Runs().Equal(b.Runs()) &&
+		a.Version() == b.Version() && a.SubVersion() == b.SubVersion()
+}
@@ -0,0 +1,198 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+func testPath(t *testing.T) ocdb.Path {
+	t.Helper()
+	p, err := ocdb.ParsePath("MUON/Calib/Gains")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSelections(t *testing.T) {
+	p := testPath(t)
+	var (
+		v1   = ocdb.NewID(p, ocdb.NewRunRange(0, 100), 1, 0)
+		v2   = ocdb.NewID(p, ocdb.NewRunRange(50, 60), 2, 0)
+		v2s1 = ocdb.NewID(p, ocdb.NewRunRange(55, 60), 2, 1)
+		v3   = ocdb.NewID(p, ocdb.NewRunRange(200, ocdb.Infinity), 3, 0)
+	)
+
+	got := selections([]ocdb.ID{v1, v2, v2s1, v3})
+	want := []segment{
+		{first: 0, last: 49, id: v1},
+		{first: 50, last: 54, id: v2},
+		{first: 55, last: 60, id: v2s1},
+		{first: 61, last: 100, id: v1},
+		{first: 200, last: int64(ocdb.Infinity), id: v3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid selections:\ngot= %+v\nwant=%+v", got, want)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	p := testPath(t)
+	id := func(first, last, vers int32) ocdb.ID {
+		return ocdb.NewID(p, ocdb.NewRunRange(first, last), vers, 0)
+	}
+	seg := func(first, last int32, vers int32, hash string) segment {
+		return segment{first: int64(first), last: int64(last), id: id(first, last, vers), hash: hash}
+	}
+
+	for _, tc := range []struct {
+		name string
+		segs []segment
+		want []group
+	}{
+		{
+			name: "identical",
+			segs: []segment{seg(0, 9, 1, "a"), seg(10, 19, 1, "a"), seg(20, 29, 1, "a")},
+			want: []group{{first: 0, last: 29, ids: []ocdb.ID{id(0, 9, 1), id(10, 19, 1), id(20, 29, 1)}, hash: "a"}},
+		},
+		{
+			name: "different-payloads",
+			segs: []segment{seg(0, 9, 1, "a"), seg(10, 19, 1, "b"), seg(20, 29, 1, "b"), seg(30, 39, 1, "a")},
+			want: []group{{first: 10, last: 29, ids: []ocdb.ID{id(10, 19, 1), id(20, 29, 1)}, hash: "b"}},
+		},
+		{
+			name: "gap",
+			segs: []segment{seg(0, 9, 1, "a"), seg(20, 29, 1, "a")},
+		},
+		{
+			name: "single-file",
+			segs: []segment{seg(0, 9, 1, "a")},
+		},
+		{
+			name: "same-file-twice",
+			segs: []segment{
+				{first: 0, last: 49, id: id(0, 100, 1), hash: "a"},
+				{first: 50, last: 60, id: id(50, 60, 2), hash: "a"},
+				{first: 61, last: 100, id: id(0, 100, 1), hash: "a"},
+			},
+			want: []group{{first: 0, last: 100, ids: []ocdb.ID{id(0, 100, 1), id(50, 60, 2)}, hash: "a"}},
+		},
+		{
+			name: "same-file-only",
+			segs: []segment{
+				{first: 0, last: 49, id: id(0, 100, 1), hash: "a"},
+				{first: 50, last: 60, id: id(50, 60, 2), hash: "b"},
+				{first: 61, last: 100, id: id(0, 100, 1), hash: "a"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := range tc.want {
+				tc.want[i].path = p.Name()
+			}
+			got := groups(p.Name(), tc.segs)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("invalid groups:\ngot= %+v\nwant=%+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	p := testPath(t)
+	dir, err := ioutil.TempDir("", "ocdb-compact-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ocdb.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, put := range []struct {
+		first, last int32
+		payload     string
+		resp        string
+	}{
+		{0, 9, "gains", "first"},
+		{10, 19, "gains", "second"},
+		{20, 29, "gains", "third"},
+		{30, 39, "other gains", "fourth"},
+	} {
+		meta := ocdb.NewMetaData(put.resp, 0, "v5", "")
+		id := ocdb.NewID(p, ocdb.NewRunRange(put.first, put.last), -1, -1)
+		_, err := db.Put(ocdb.NewEntry(rbase.NewObjString(put.payload), id, meta, true))
+		if err != nil {
+			t.Fatalf("could not store entry: %+v", err)
+		}
+	}
+
+	ids, err := db.List(p.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	segs := selections(ids)
+	for i := range segs {
+		entry, err := db.Load(segs[i].id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		segs[i].hash, err = ocdb.PayloadHash(entry.Object())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	gs := groups(p.Name(), segs)
+	if len(gs) != 1 || gs[0].first != 0 || gs[0].last != 29 || len(gs[0].ids) != 3 {
+		t.Fatalf("invalid groups: %+v", gs)
+	}
+
+	id, err := merge(db, db, gs[0])
+	if err != nil {
+		t.Fatalf("could not merge entries: %+v", err)
+	}
+	if got, want := filename(id), "Run0_29_v2_s0.root"; got != want {
+		t.Fatalf("invalid merged file: got=%q, want=%q", got, want)
+	}
+
+	for _, tc := range []struct {
+		run     int32
+		payload string
+		resp    string
+	}{
+		{0, "gains", "third"},
+		{15, "gains", "third"},
+		{29, "gains", "third"},
+		{35, "other gains", "fourth"},
+	} {
+		entry, err := db.Get(p.Name(), tc.run)
+		if err != nil {
+			t.Fatalf("run %d: could not get entry: %+v", tc.run, err)
+		}
+		if got := entry.Object().(*rbase.ObjString).String(); got != tc.payload {
+			t.Fatalf("run %d: invalid payload: got=%q, want=%q", tc.run, got, tc.payload)
+		}
+		if got := entry.MetaData().Responsible(); got != tc.resp {
+			t.Fatalf("run %d: invalid responsible: got=%q, want=%q", tc.run, got, tc.resp)
+		}
+	}
+
+	entry, err := db.Load(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prop, ok := entry.MetaData().Property("CompactedFrom")
+	if want := "Run0_9_v1_s0.root...Run20_29_v1_s0.root (3 files)"; !ok || prop != want {
+		t.Fatalf("invalid CompactedFrom property: got=%q, want=%q", prop, want)
+	}
+}
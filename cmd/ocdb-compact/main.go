@@ -0,0 +1,158 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-compact finds, in a local OCDB storage, consecutive runs
+// for which the selected files hold identical payloads, and proposes to
+// replace them with a single entry valid for the merged run range.
+//
+// Payloads are compared with ocdb.PayloadHash, so IDs and metadata are ignored.
+// With -write, the merged entries are stored, with a new version, in the
+// storage itself or in the -o storage.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	_ "go-hep.org/x/hep/groot/ztypes"
+)
+
+func main() {
+	log.SetPrefix("ocdb-compact: ")
+	log.SetFlags(0)
+
+	var (
+		pattern = flag.String("path", "*/*/*", "only compact paths matching this pattern")
+		write   = flag.Bool("write", false, "write the merged entries (default: only list them)")
+		oname   = flag.String("o", "", "storage where merged entries are written (default: the input storage)")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-compact [options] storage-dir\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := ocdb.NewLocal(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	out := db
+	if *oname != "" {
+		err = os.MkdirAll(*oname, 0755)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out, err = ocdb.NewLocal(*oname)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	out.SetWarner(func(msg string) { log.Printf("warning: %s", msg) })
+
+	ids, err := db.List(*pattern)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	var (
+		hashes = make(map[string]string) // payload hashes, by file name
+		nerrs  = 0
+		nfiles = 0
+		gs     []group
+	)
+	hash := func(id ocdb.ID) string {
+		fname := db.Filename(id)
+		if h, ok := hashes[fname]; ok {
+			return h
+		}
+		entry, err := db.Load(id)
+		if err == nil {
+			hashes[fname], err = ocdb.PayloadHash(entry.Object())
+		}
+		if err != nil {
+			log.Printf("error: %s: %v", fname, err)
+			nerrs++
+			hashes[fname] = "error: " + fname // never merged
+		}
+		return hashes[fname]
+	}
+
+	for i := 0; i < len(ids); {
+		j := i + 1
+		for j < len(ids) && ids[j].Path().Name() == ids[i].Path().Name() {
+			j++
+		}
+		segs := selections(ids[i:j])
+		for k := range segs {
+			segs[k].hash = hash(segs[k].id)
+		}
+		gs = append(gs, groups(ids[i].Path().Name(), segs)...)
+		i = j
+	}
+
+	for _, g := range gs {
+		nfiles += len(g.ids)
+		fmt.Printf("%s: [%d, %d] <- %d files (%s ... %s), payload %.12s\n",
+			g.path, g.first, g.last, len(g.ids),
+			filename(g.ids[0]), filename(g.ids[len(g.ids)-1]), g.hash,
+		)
+		if !*write {
+			continue
+		}
+
+		id, err := merge(db, out, g)
+		if err != nil {
+			log.Printf("error: %s: [%d, %d]: %+v", g.path, g.first, g.last, err)
+			nerrs++
+			continue
+		}
+		fmt.Printf("%s: wrote %s\n", g.path, out.Filename(id))
+	}
+
+	switch {
+	case *write:
+		log.Printf("%d merged entries written, replacing %d files", len(gs), nfiles)
+	default:
+		log.Printf("%d merged entries would replace %d files (use -write to write them)", len(gs), nfiles)
+	}
+
+	if nerrs > 0 {
+		os.Exit(1)
+	}
+}
+
+// merge stores into out an entry valid for the run range of g, with the
+// payload and metadata of the last file of g.
+// The entry is given the next version available in out.
+func merge(db, out *ocdb.Local, g group) (ocdb.ID, error) {
+	last := g.ids[len(g.ids)-1]
+	entry, err := db.Load(last)
+	if err != nil {
+		return ocdb.ID{}, err
+	}
+
+	meta := entry.MetaData()
+	if meta == nil {
+		meta = ocdb.NewMetaData("", 0, "", "")
+	}
+	meta.SetProperty("CompactedFrom", fmt.Sprintf("%s...%s (%d files)", filename(g.ids[0]), filename(last), len(g.ids)))
+
+	id := ocdb.NewID(last.Path(), g.runs(), -1, -1)
+	return out.Put(ocdb.NewEntry(entry.Object(), id, meta, true))
+}
+
+func filename(id ocdb.ID) string {
+	return ocdb.FormatFilename(id.Runs(), id.Version(), id.SubVersion())
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/alice-go/aligo/ocdb"
+)
+
+// coverage describes the runs covered by the files of a path.
+type coverage struct {
+	Path     string          `json:"path"`
+	Files    int             `json:"files"`
+	Covered  []ocdb.RunRange `json:"covered"`  // merged run ranges of all the files
+	Gaps     []ocdb.RunRange `json:"gaps"`     // runs not covered, between covered ranges
+	Overlaps []overlap       `json:"overlaps"` // pairs of files with overlapping run ranges
+	Multiple []multiple      `json:"multiple"` // runs covered by several versions
+
+	// Only filled (and otherwise null) when a list of expected runs is provided.
+	Missing         []int32 `json:"missing"`         // expected runs not covered
+	MultiplyCovered []int32 `json:"multiplyCovered"` // expected runs covered by several versions
+}
+
+type overlap struct {
+	A    string        `json:"a"`
+	B    string        `json:"b"`
+	Runs ocdb.RunRange `json:"runs"` // runs covered by both files
+}
+
+type multiple struct {
+	Runs     ocdb.RunRange `json:"runs"`
+	Versions []string      `json:"versions"`
+}
+
+// segment is a run range covered by the same set of files.
+type segment struct {
+	first, last int64
+	ids         []ocdb.ID
+}
+
+func filename(id ocdb.ID) string {
+	return ocdb.FormatFilename(id.Runs(), id.Version(), id.SubVersion())
+}
+
+func version(id ocdb.ID) string {
+	return fmt.Sprintf("v%d_s%d", id.Version(), id.SubVersion())
+}
+
+func runRange(first, last int64) ocdb.RunRange {
+	return ocdb.NewRunRange(int32(first), int32(last))
+}
+
+// newCoverage computes the coverage of ids, all sharing the same path.
+// If runs is not nil, it also reports the expected runs that are not covered,
+// or covered by several versions.
+func newCoverage(path string, ids []ocdb.ID, runs []int32) coverage {
+	cov := coverage{
+		Path:     path,
+		Files:    len(ids),
+		Covered:  []ocdb.RunRange{},
+		Gaps:     []ocdb.RunRange{},
+		Overlaps: []overlap{},
+		Multiple: []multiple{},
+	}
+
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			if runs, ok := a.Runs().Intersect(b.Runs()); ok {
+				cov.Overlaps = append(cov.Overlaps, overlap{A: filename(a), B: filename(b), Runs: runs})
+			}
+		}
+	}
+
+	segs := segments(ids)
+	for i, seg := range segs {
+		n := len(cov.Covered)
+		switch {
+		case len(seg.ids) == 0:
+			cov.Gaps = append(cov.Gaps, runRange(seg.first, seg.last))
+			continue
+		case n > 0 && i > 0 && len(segs[i-1].ids) > 0:
+			cov.Covered[n-1].Last = int32(seg.last)
+		default:
+			cov.Covered = append(cov.Covered, runRange(seg.first, seg.last))
+		}
+		if len(seg.ids) > 1 {
+			m := multiple{Runs: runRange(seg.first, seg.last)}
+			for _, id := range seg.ids {
+				m.Versions = append(m.Versions, version(id))
+			}
+			cov.Multiple = append(cov.Multiple, m)
+		}
+	}
+
+	if runs == nil {
+		return cov
+	}
+	cov.Missing = []int32{}
+	cov.MultiplyCovered = []int32{}
+	for _, run := range runs {
+		i := sort.Search(len(segs), func(i int) bool { return segs[i].last >= int64(run) })
+		switch {
+		case i == len(segs) || segs[i].first > int64(run) || len(segs[i].ids) == 0:
+			cov.Missing = append(cov.Missing, run)
+		case len(segs[i].ids) > 1:
+			cov.MultiplyCovered = append(cov.MultiplyCovered, run)
+		}
+	}
+	return cov
+}
+
+// segments splits the runs covered by ids into consecutive segments
+// covered by the same files, from the first to the last covered run.
+// Segments not covered by any file are gaps.
+func segments(ids []ocdb.ID) []segment {
+	var bounds []int64
+	for _, id := range ids {
+		bounds = append(bounds, int64(id.Runs().First), int64(id.Runs().Last)+1)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	var segs []segment
+	for i := 0; i+1 < len(bounds); i++ {
+		if bounds[i] == bounds[i+1] {
+			continue
+		}
+		seg := segment{first: bounds[i], last: bounds[i+1] - 1}
+		for _, id := range ids {
+			if int64(id.Runs().First) <= seg.first && seg.last <= int64(id.Runs().Last) {
+				seg.ids = append(seg.ids, id)
+			}
+		}
+		segs = append(segs, seg)
+	}
+
+	// merge consecutive segments covered by the same files.
+	var out []segment
+	for _, seg := range segs {
+		if n := len(out); n > 0 && sameIDs(out[n-1].ids, seg.ids) {
+			out[n-1].last = seg.last
+			continue
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+func sameIDs(a, b []ocdb.ID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if filename(a[i]) != filename(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alice-go/aligo/ocdb"
+)
+
+func TestCoverage(t *testing.T) {
+	const path = "MUON/Calib/Pedestals"
+	p, err := ocdb.ParsePath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := func(first, last, vers int32) ocdb.ID {
+		return ocdb.NewID(p, ocdb.NewRunRange(first, last), vers, 0)
+	}
+	rr := ocdb.NewRunRange
+
+	for _, tc := range []struct {
+		name string
+		ids  []ocdb.ID
+		runs []int32
+		want coverage
+	}{
+		{
+			name: "single",
+			ids:  []ocdb.ID{id(0, 10, 1)},
+			want: coverage{
+				Covered: []ocdb.RunRange{rr(0, 10)},
+			},
+		},
+		{
+			name: "gap",
+			ids:  []ocdb.ID{id(0, 10, 1), id(20, 30, 1)},
+			want: coverage{
+				Covered: []ocdb.RunRange{rr(0, 10), rr(20, 30)},
+				Gaps:    []ocdb.RunRange{rr(11, 19)},
+			},
+		},
+		{
+			name: "adjacent",
+			ids:  []ocdb.ID{id(0, 10, 1), id(11, 20, 1), id(21, 30, 2)},
+			want: coverage{
+				Covered: []ocdb.RunRange{rr(0, 30)},
+			},
+		},
+		{
+			name: "overlap",
+			ids:  []ocdb.ID{id(0, 100, 1), id(50, 150, 2)},
+			want: coverage{
+				Covered: []ocdb.RunRange{rr(0, 150)},
+				Overlaps: []overlap{
+					{A: "Run0_100_v1_s0.root", B: "Run50_150_v2_s0.root", Runs: rr(50, 100)},
+				},
+				Multiple: []multiple{
+					{Runs: rr(50, 100), Versions: []string{"v1_s0", "v2_s0"}},
+				},
+			},
+		},
+		{
+			name: "overlaps-and-gaps",
+			ids:  []ocdb.ID{id(0, 10, 1), id(5, 8, 2), id(5, 12, 3), id(20, 30, 1)},
+			want: coverage{
+				Covered: []ocdb.RunRange{rr(0, 12), rr(20, 30)},
+				Gaps:    []ocdb.RunRange{rr(13, 19)},
+				Overlaps: []overlap{
+					{A: "Run0_10_v1_s0.root", B: "Run5_8_v2_s0.root", Runs: rr(5, 8)},
+					{A: "Run0_10_v1_s0.root", B: "Run5_12_v3_s0.root", Runs: rr(5, 10)},
+					{A: "Run5_8_v2_s0.root", B: "Run5_12_v3_s0.root", Runs: rr(5, 8)},
+				},
+				Multiple: []multiple{
+					{Runs: rr(5, 8), Versions: []string{"v1_s0", "v2_s0", "v3_s0"}},
+					{Runs: rr(9, 10), Versions: []string{"v1_s0", "v3_s0"}},
+				},
+			},
+		},
+		{
+			name: "infinity",
+			ids:  []ocdb.ID{id(0, ocdb.Infinity, 1), id(100, 200, 2), id(500, ocdb.Infinity, 3)},
+			want: coverage{
+				Covered: []ocdb.RunRange{rr(0, ocdb.Infinity)},
+				Overlaps: []overlap{
+					{A: "Run0_999999999_v1_s0.root", B: "Run100_200_v2_s0.root", Runs: rr(100, 200)},
+					{A: "Run0_999999999_v1_s0.root", B: "Run500_999999999_v3_s0.root", Runs: rr(500, ocdb.Infinity)},
+				},
+				Multiple: []multiple{
+					{Runs: rr(100, 200), Versions: []string{"v1_s0", "v2_s0"}},
+					{Runs: rr(500, ocdb.Infinity), Versions: []string{"v1_s0", "v3_s0"}},
+				},
+			},
+		},
+		{
+			name: "expected-runs",
+			ids:  []ocdb.ID{id(0, 10, 1), id(5, 10, 2), id(20, ocdb.Infinity, 1)},
+			runs: []int32{0, 7, 15, 20, ocdb.Infinity},
+			want: coverage{
+				Covered: []ocdb.RunRange{rr(0, 10), rr(20, ocdb.Infinity)},
+				Gaps:    []ocdb.RunRange{rr(11, 19)},
+				Overlaps: []overlap{
+					{A: "Run0_10_v1_s0.root", B: "Run5_10_v2_s0.root", Runs: rr(5, 10)},
+				},
+				Multiple: []multiple{
+					{Runs: rr(5, 10), Versions: []string{"v1_s0", "v2_s0"}},
+				},
+				Missing:         []int32{15},
+				MultiplyCovered: []int32{7},
+			},
+		},
+		{
+			name: "expected-runs-outside",
+			ids:  []ocdb.ID{id(10, 20, 1)},
+			runs: []int32{5, 10, 20, 25},
+			want: coverage{
+				Covered:         []ocdb.RunRange{rr(10, 20)},
+				Missing:         []int32{5, 25},
+				MultiplyCovered: []int32{},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			want := tc.want
+			want.Path = path
+			want.Files = len(tc.ids)
+			if want.Gaps == nil {
+				want.Gaps = []ocdb.RunRange{}
+			}
+			if want.Overlaps == nil {
+				want.Overlaps = []overlap{}
+			}
+			if want.Multiple == nil {
+				want.Multiple = []multiple{}
+			}
+
+			got := newCoverage(path, tc.ids, tc.runs)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("invalid coverage:\ngot= %+v\nwant=%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSegments(t *testing.T) {
+	p, err := ocdb.ParsePath("MUON/Calib/Pedestals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		v1 = ocdb.NewID(p, ocdb.NewRunRange(0, 10), 1, 0)
+		v2 = ocdb.NewID(p, ocdb.NewRunRange(5, 20), 2, 0)
+		v3 = ocdb.NewID(p, ocdb.NewRunRange(30, ocdb.Infinity), 3, 0)
+	)
+
+	got := segments([]ocdb.ID{v1, v2, v3})
+	want := []segment{
+		{first: 0, last: 4, ids: []ocdb.ID{v1}},
+		{first: 5, last: 10, ids: []ocdb.ID{v1, v2}},
+		{first: 11, last: 20, ids: []ocdb.ID{v2}},
+		{first: 21, last: 29},
+		{first: 30, last: int64(ocdb.Infinity), ids: []ocdb.ID{v3}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid segments:\ngot= %+v\nwant=%+v", got, want)
+	}
+
+	if got := segments(nil); len(got) != 0 {
+		t.Fatalf("invalid segments without files: %+v", got)
+	}
+}
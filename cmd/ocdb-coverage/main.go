@@ -0,0 +1,142 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-coverage reports, for each path of a local OCDB storage,
+// the runs covered by its files, the gaps and overlaps between their run
+// ranges, and the runs covered by several versions.
+//
+// Given a list of expected runs, it also reports the runs that are not
+// covered, and exits with a non-zero status if there is any.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alice-go/aligo/ocdb"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	log.SetPrefix("ocdb-coverage: ")
+	log.SetFlags(0)
+
+	var (
+		pattern = flag.String("path", "*/*/*", "only report paths matching this pattern")
+		runList = flag.String("runs", "", "file listing the expected run numbers")
+		doJSON  = flag.Bool("json", false, "print the report as JSON")
+		index   = flag.String("index", "", "index file of the storage (default: <dir>/"+ocdb.IndexFile+", if it exists)")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-coverage [options] storage-dir\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var runs []int32
+	if *runList != "" {
+		var err error
+		runs, err = ocdb.ReadRunList(*runList)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	db, err := ocdb.NewLocal(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	err = db.LoadIndex(*index)
+	switch {
+	case errors.Cause(err) == ocdb.ErrStaleIndex:
+		log.Printf("%v: scanning directories instead", err)
+	case err != nil:
+		log.Fatalf("%+v", err)
+	}
+
+	ids, err := db.List(*pattern)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	covs := []coverage{}
+	for i := 0; i < len(ids); {
+		j := i + 1
+		for j < len(ids) && ids[j].Path().Name() == ids[i].Path().Name() {
+			j++
+		}
+		covs = append(covs, newCoverage(ids[i].Path().Name(), ids[i:j], runs))
+		i = j
+	}
+
+	if *doJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(covs)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printText(os.Stdout, covs)
+	}
+
+	nmiss := 0
+	for _, cov := range covs {
+		nmiss += len(cov.Missing)
+	}
+	if nmiss > 0 {
+		log.Fatalf("%d expected run(s) not covered", nmiss)
+	}
+}
+
+func printText(w io.Writer, covs []coverage) {
+	ranges := func(rs []ocdb.RunRange) string {
+		if len(rs) == 0 {
+			return "none"
+		}
+		strs := make([]string, len(rs))
+		for i, r := range rs {
+			strs[i] = fmt.Sprintf("[%d, %d]", r.First, r.Last)
+		}
+		return strings.Join(strs, " ")
+	}
+	runs := func(vs []int32) string {
+		if len(vs) == 0 {
+			return "none"
+		}
+		strs := make([]string, len(vs))
+		for i, v := range vs {
+			strs[i] = strconv.Itoa(int(v))
+		}
+		return strings.Join(strs, " ")
+	}
+
+	for _, cov := range covs {
+		fmt.Fprintf(w, "%s: %d file(s)\n", cov.Path, cov.Files)
+		fmt.Fprintf(w, "  covered: %s\n", ranges(cov.Covered))
+		fmt.Fprintf(w, "  gaps:    %s\n", ranges(cov.Gaps))
+		for _, o := range cov.Overlaps {
+			fmt.Fprintf(w, "  overlap: %s and %s on [%d, %d]\n", o.A, o.B, o.Runs.First, o.Runs.Last)
+		}
+		for _, m := range cov.Multiple {
+			fmt.Fprintf(w, "  several versions on [%d, %d]: %s\n", m.Runs.First, m.Runs.Last, strings.Join(m.Versions, " "))
+		}
+		if cov.Missing != nil {
+			fmt.Fprintf(w, "  expected runs not covered: %s\n", runs(cov.Missing))
+			fmt.Fprintf(w, "  expected runs covered by several versions: %s\n", runs(cov.MultiplyCovered))
+		}
+	}
+}
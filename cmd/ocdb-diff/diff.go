@@ -0,0 +1,374 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/root"
+)
+
+// report describes the differences between two entries.
+type report struct {
+	A          string       `json:"a"`
+	B          string       `json:"b"`
+	ID         []change     `json:"id"`
+	MetaData   []change     `json:"metadata"`
+	Properties []change     `json:"properties"`
+	Payload    *payloadDiff `json:"payload"`
+}
+
+// change is a value that differs between the two entries.
+// A missing value is nil.
+type change struct {
+	Field string      `json:"field"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
+}
+
+type payloadDiff struct {
+	ClassA  string    `json:"classA"`
+	ClassB  string    `json:"classB"`
+	Changes []change  `json:"changes,omitempty"` // structural differences of generic payloads
+	Manus   *manuDiff `json:"manus,omitempty"`   // differences of AliMUON2DMap payloads
+}
+
+type manu struct {
+	DE   int `json:"de"`
+	Manu int `json:"manu"`
+}
+
+type manuDiff struct {
+	Added    []manu       `json:"added"`
+	Removed  []manu       `json:"removed"`
+	Reshaped []manu       `json:"reshaped"` // manus whose number of channels or dimension changed
+	Objects  []objectDiff `json:"objects"`  // manus whose objects are not both AliMUONCalibParamND, and differ
+	Deltas   []delta      `json:"deltas"`
+}
+
+// objectDiff describes the differences between the objects of a manu,
+// compared as generic payloads.
+type objectDiff struct {
+	DE      int      `json:"de"`
+	Manu    int      `json:"manu"`
+	ClassA  string   `json:"classA"`
+	ClassB  string   `json:"classB"`
+	Changes []change `json:"changes"`
+}
+
+// delta is a channel value that changed by more than the tolerance.
+type delta struct {
+	DE      int   `json:"de"`
+	Manu    int   `json:"manu"`
+	Channel int   `json:"channel"`
+	Dim     int   `json:"dim"`
+	A       float `json:"a"`
+	B       float `json:"b"`
+	Delta   float `json:"delta"`
+}
+
+func (r *report) empty() bool {
+	return len(r.ID) == 0 && len(r.MetaData) == 0 && len(r.Properties) == 0 &&
+		(r.Payload == nil || (r.Payload.ClassA == r.Payload.ClassB && len(r.Payload.Changes) == 0 &&
+			(r.Payload.Manus == nil || r.Payload.Manus.empty())))
+}
+
+func (d *manuDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Reshaped) == 0 &&
+		len(d.Objects) == 0 && len(d.Deltas) == 0
+}
+
+func diffEntries(a, b *ocdb.Entry, tol float64) (*report, error) {
+	r := &report{
+		ID:         []change{},
+		MetaData:   []change{},
+		Properties: []change{},
+	}
+
+	ida, idb := a.Id(), b.Id()
+	cmp(&r.ID, "path", ida.Path().Name(), idb.Path().Name())
+	cmp(&r.ID, "runs", fmt.Sprintf("[%d, %d]", ida.Runs().First, ida.Runs().Last), fmt.Sprintf("[%d, %d]", idb.Runs().First, idb.Runs().Last))
+	cmp(&r.ID, "version", ida.Version(), idb.Version())
+	cmp(&r.ID, "subversion", ida.SubVersion(), idb.SubVersion())
+
+	ma, mb := a.MetaData(), b.MetaData()
+	if ma == nil {
+		ma = ocdb.NewMetaData("", 0, "", "")
+	}
+	if mb == nil {
+		mb = ocdb.NewMetaData("", 0, "", "")
+	}
+	cmp(&r.MetaData, "class", ma.ObjectClassName(), mb.ObjectClassName())
+	cmp(&r.MetaData, "responsible", ma.Responsible(), mb.Responsible())
+	cmp(&r.MetaData, "beamPeriod", ma.BeamPeriod(), mb.BeamPeriod())
+	cmp(&r.MetaData, "aliRootVersion", ma.AliRootVersion(), mb.AliRootVersion())
+	cmp(&r.MetaData, "comment", ma.Comment(), mb.Comment())
+
+	pa, pb := ma.Properties(), mb.Properties()
+	for _, k := range keys(pa, pb) {
+		va, oka := pa[k]
+		vb, okb := pb[k]
+		switch {
+		case !oka:
+			r.Properties = append(r.Properties, change{Field: k, B: vb})
+		case !okb:
+			r.Properties = append(r.Properties, change{Field: k, A: va})
+		case va != vb:
+			r.Properties = append(r.Properties, change{Field: k, A: va, B: vb})
+		}
+	}
+
+	payload, err := diffPayloads(a.Object(), b.Object(), tol)
+	if err != nil {
+		return nil, err
+	}
+	r.Payload = payload
+
+	return r, nil
+}
+
+func cmp(cs *[]change, field string, a, b interface{}) {
+	if a != b {
+		*cs = append(*cs, change{Field: field, A: a, B: b})
+	}
+}
+
+func keys(ms ...map[string]string) []string {
+	set := make(map[string]struct{})
+	for _, m := range ms {
+		for k := range m {
+			set[k] = struct{}{}
+		}
+	}
+	ks := make([]string, 0, len(set))
+	for k := range set {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func className(obj root.Object) string {
+	if obj == nil {
+		return ""
+	}
+	return obj.Class()
+}
+
+func diffPayloads(a, b root.Object, tol float64) (*payloadDiff, error) {
+	d := &payloadDiff{ClassA: className(a), ClassB: className(b)}
+
+	if ma, ok := a.(*muoncalib.Map2D); ok {
+		if mb, ok := b.(*muoncalib.Map2D); ok {
+			manus, err := diffMap2D(ma, mb, tol)
+			if err != nil {
+				return nil, err
+			}
+			d.Manus = manus
+			return d, nil
+		}
+	}
+
+	changes, err := diffObjects(a, b, tol)
+	if err != nil {
+		return nil, err
+	}
+	d.Changes = changes
+	return d, nil
+}
+
+// diffObjects returns the differences between the generic JSON
+// representations of two objects.
+func diffObjects(a, b root.Object, tol float64) ([]change, error) {
+	ja, err := decode(a)
+	if err != nil {
+		return nil, err
+	}
+	jb, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+	cs := []change{}
+	diffJSON(&cs, "", ja, jb, tol)
+	return cs, nil
+}
+
+// decode returns the generic JSON representation of obj.
+func decode(obj root.Object) (interface{}, error) {
+	raw, err := ocdb.MarshalObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	err = json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// diffJSON appends to cs the differences between two generic JSON values.
+func diffJSON(cs *[]change, field string, a, b interface{}, tol float64) {
+	switch va := a.(type) {
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		ks := make([]string, 0, len(va)+len(vb))
+		for k := range va {
+			ks = append(ks, k)
+		}
+		for k := range vb {
+			if _, dup := va[k]; !dup {
+				ks = append(ks, k)
+			}
+		}
+		sort.Strings(ks)
+		for _, k := range ks {
+			diffJSON(cs, field+"/"+k, va[k], vb[k], tol)
+		}
+		return
+
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		n := len(va)
+		if len(vb) > n {
+			n = len(vb)
+		}
+		for i := 0; i < n; i++ {
+			var ea, eb interface{}
+			if i < len(va) {
+				ea = va[i]
+			}
+			if i < len(vb) {
+				eb = vb[i]
+			}
+			diffJSON(cs, field+"/"+strconv.Itoa(i), ea, eb, tol)
+		}
+		return
+
+	case float64:
+		if vb, ok := b.(float64); ok {
+			if math.Abs(va-vb) > tol {
+				*cs = append(*cs, change{Field: field, A: va, B: vb})
+			}
+			return
+		}
+	}
+
+	if fmt.Sprint(a) != fmt.Sprint(b) {
+		*cs = append(*cs, change{Field: field, A: a, B: b})
+	}
+}
+
+// diffMap2D compares two maps manu by manu.
+// Manus whose objects are not both AliMUONCalibParamND are compared as
+// generic payloads.
+func diffMap2D(a, b *muoncalib.Map2D, tol float64) (*manuDiff, error) {
+	d := &manuDiff{
+		Added:    []manu{},
+		Removed:  []manu{},
+		Reshaped: []manu{},
+		Objects:  []objectDiff{},
+		Deltas:   []delta{},
+	}
+
+	inb := make(map[muoncalib.Manu]bool)
+	for _, m := range manus(b) {
+		inb[m] = true
+	}
+	ina := make(map[muoncalib.Manu]bool)
+	for _, m := range manus(a) {
+		ina[m] = true
+		if !inb[m] {
+			d.Removed = append(d.Removed, manu{m.DeID, m.ID})
+			continue
+		}
+		oa, ob := a.GetObject(m.DeID, m.ID), b.GetObject(m.DeID, m.ID)
+		pa, oka := oa.(*muoncalib.ParamND)
+		pb, okb := ob.(*muoncalib.ParamND)
+		if !oka || !okb {
+			cs, err := diffObjects(oa, ob, tol)
+			if err != nil {
+				return nil, err
+			}
+			if ca, cb := className(oa), className(ob); ca != cb || len(cs) > 0 {
+				d.Objects = append(d.Objects, objectDiff{
+					DE: m.DeID, Manu: m.ID, ClassA: ca, ClassB: cb, Changes: cs,
+				})
+			}
+			continue
+		}
+		if pa.Size() != pb.Size() || pa.Dimension() != pb.Dimension() {
+			d.Reshaped = append(d.Reshaped, manu{m.DeID, m.ID})
+		}
+		d.Deltas = append(d.Deltas, diffParams(m, pa, pb, tol)...)
+	}
+	for _, m := range manus(b) {
+		if !ina[m] {
+			d.Added = append(d.Added, manu{m.DeID, m.ID})
+		}
+	}
+
+	return d, nil
+}
+
+func manus(m *muoncalib.Map2D) []muoncalib.Manu {
+	if m.ExMap() == nil {
+		return nil
+	}
+	return m.GetManus()
+}
+
+// diffParams returns the channel values of two calibration parameters
+// that differ by more than tol.
+// Only the channels and dimensions common to both parameters are compared.
+func diffParams(m muoncalib.Manu, a, b *muoncalib.ParamND, tol float64) []delta {
+	var ds []delta
+	size := minInt(a.Size(), b.Size())
+	dim := minInt(a.Dimension(), b.Dimension())
+	for i := 0; i < size; i++ {
+		for j := 0; j < dim; j++ {
+			va, vb := a.Value(i, j), b.Value(i, j)
+			if math.IsNaN(va) && math.IsNaN(vb) {
+				continue
+			}
+			diff := vb - va
+			if math.Abs(diff) <= tol {
+				continue
+			}
+			ds = append(ds, delta{
+				DE: m.DeID, Manu: m.ID, Channel: i, Dim: j,
+				A: float(va), B: float(vb), Delta: float(diff),
+			})
+		}
+	}
+	return ds
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// float is a float64 whose JSON encoding supports NaN and infinities, as strings.
+type float float64
+
+func (v float) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return json.Marshal(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return json.Marshal(f)
+}
@@ -0,0 +1,271 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/root"
+)
+
+// newParam returns a parameter of size channels and dim values per channel,
+// with value v0+i+j for channel i and dimension j.
+func newParam(de, manu, size, dim int, v0 float64) *muoncalib.ParamND {
+	p := muoncalib.NewParamND(dim, size, uint32(de), uint32(manu))
+	for i := 0; i < size; i++ {
+		for j := 0; j < dim; j++ {
+			p.SetValue(i, j, v0+float64(i+j))
+		}
+	}
+	return p
+}
+
+// newMap returns a map holding objs, by manu.
+func newMap(objs map[manu]root.Object) *muoncalib.Map2D {
+	m := muoncalib.NewMap2D(true)
+	for k, obj := range objs {
+		m.Add(k.DE, k.Manu, obj)
+	}
+	return m
+}
+
+func newEntry(obj root.Object, vers int32, meta *ocdb.MetaData) *ocdb.Entry {
+	id := ocdb.NewID(ocdb.NewPath("MUON", "Calib", "Pedestals"), ocdb.NewRunRange(1, 2), vers, 0)
+	return ocdb.NewEntry(obj, id, meta, true)
+}
+
+func TestDiffMap2D(t *testing.T) {
+	shifted := newParam(100, 5, 3, 2, 1)
+	shifted.SetValue(1, 1, shifted.Value(1, 1)+0.5)  // above tolerance
+	shifted.SetValue(2, 0, shifted.Value(2, 0)+0.05) // below tolerance
+
+	a := newMap(map[manu]root.Object{
+		{100, 5}:  newParam(100, 5, 3, 2, 1),
+		{100, 6}:  newParam(100, 6, 3, 2, 1),
+		{100, 7}:  newParam(100, 7, 3, 2, 1),
+		{100, 8}:  newParam(100, 8, 3, 2, 1),
+		{200, 1}:  newParam(200, 1, 3, 2, 1),
+		{200, 2}:  rbase.NewObjString("same"),
+		{200, 3}:  rbase.NewObjString("old"),
+		{1025, 1}: newParam(1025, 1, 3, 2, 1),
+	})
+	b := newMap(map[manu]root.Object{
+		{100, 5}:  shifted,
+		{100, 6}:  newParam(100, 6, 3, 2, 1),
+		{100, 7}:  newParam(100, 7, 4, 2, 1),
+		{100, 9}:  newParam(100, 9, 3, 2, 1),
+		{200, 1}:  rbase.NewObjString("not a param"),
+		{200, 2}:  rbase.NewObjString("same"),
+		{200, 3}:  rbase.NewObjString("new"),
+		{1025, 1}: newParam(1025, 1, 3, 2, 1),
+	})
+
+	d, err := diffMap2D(a, b, 0.1)
+	if err != nil {
+		t.Fatalf("could not compare maps: %+v", err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"added", d.Added, []manu{{100, 9}}},
+		{"removed", d.Removed, []manu{{100, 8}}},
+		{"reshaped", d.Reshaped, []manu{{100, 7}}},
+		{"deltas", d.Deltas, []delta{{DE: 100, Manu: 5, Channel: 1, Dim: 1, A: 3, B: 3.5, Delta: 0.5}}},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Fatalf("invalid %s manus:\ngot= %+v\nwant=%+v", tc.name, tc.got, tc.want)
+		}
+	}
+
+	if got, want := len(d.Objects), 2; got != want {
+		t.Fatalf("invalid number of differing objects: got=%d, want=%d (%+v)", got, want, d.Objects)
+	}
+	if o := d.Objects[0]; o.DE != 200 || o.Manu != 1 || o.ClassA != "AliMUONCalibParamND" || o.ClassB != "TObjString" {
+		t.Fatalf("invalid type mismatch: %+v", o)
+	}
+	if o := d.Objects[1]; o.DE != 200 || o.Manu != 3 || o.ClassA != "TObjString" || o.ClassB != "TObjString" ||
+		!reflect.DeepEqual(o.Changes, []change{{Field: "/fields/str", A: "old", B: "new"}}) {
+		t.Fatalf("invalid object changes: %+v", o)
+	}
+
+	// with a larger tolerance, only the structural changes remain.
+	d, err = diffMap2D(a, b, 1)
+	if err != nil {
+		t.Fatalf("could not compare maps: %+v", err)
+	}
+	if len(d.Deltas) != 0 {
+		t.Fatalf("unexpected deltas: %+v", d.Deltas)
+	}
+
+	d, err = diffMap2D(a, a, 0)
+	if err != nil {
+		t.Fatalf("could not compare maps: %+v", err)
+	}
+	if !d.empty() {
+		t.Fatalf("map differs from itself: %+v", d)
+	}
+}
+
+func TestDiffEntriesTypeMismatch(t *testing.T) {
+	// a manu holding an object of another type must not go unnoticed.
+	a := newEntry(newMap(map[manu]root.Object{{100, 1}: newParam(100, 1, 2, 1, 1)}), 1, nil)
+	b := newEntry(newMap(map[manu]root.Object{{100, 1}: rbase.NewObjString("1")}), 1, nil)
+
+	r, err := diffEntries(a, b, 0)
+	if err != nil {
+		t.Fatalf("could not compare entries: %+v", err)
+	}
+	if r.empty() {
+		t.Fatalf("entries with different manu objects reported as identical")
+	}
+
+	var out bytes.Buffer
+	r.print(&out)
+	want := `--- 
++++ 
+Payload (AliMUON2DMap):
+  ~ manu (de=100, manu=1): class "AliMUONCalibParamND" -> "TObjString"
+`
+	if got := out.String(); got != want {
+		t.Fatalf("invalid report:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffEntries(t *testing.T) {
+	ma := ocdb.NewMetaData("alice", 0, "v5-09-38", "pedestals")
+	ma.SetProperty("RunUsed", "1")
+	ma.SetProperty("Old", "x")
+	mb := ocdb.NewMetaData("bob", 0, "v5-09-38", "pedestals")
+	mb.SetProperty("RunUsed", "2")
+	mb.SetProperty("New", "y")
+
+	pa := newMap(map[manu]root.Object{
+		{100, 5}: newParam(100, 5, 2, 2, 1),
+		{100, 8}: newParam(100, 8, 2, 2, 1),
+	})
+	pb := newMap(map[manu]root.Object{
+		{100, 5}: newParam(100, 5, 2, 2, 1.5),
+		{100, 9}: newParam(100, 9, 2, 2, 1),
+	})
+
+	a := newEntry(pa, 1, ma)
+	b := newEntry(pb, 2, mb)
+
+	r, err := diffEntries(a, b, 0.1)
+	if err != nil {
+		t.Fatalf("could not compare entries: %+v", err)
+	}
+	r.A = "Run1_2_v1_s0.root"
+	r.B = "Run1_2_v2_s0.root"
+
+	var out bytes.Buffer
+	r.print(&out)
+	want := `--- Run1_2_v1_s0.root
++++ Run1_2_v2_s0.root
+ID:
+  ~ version: 1 -> 2
+MetaData:
+  ~ responsible: alice -> bob
+Properties:
+  + New: y
+  - Old: x
+  ~ RunUsed: 1 -> 2
+Payload (AliMUON2DMap):
+  - manu (de=100, manu=8)
+  + manu (de=100, manu=9)
+  ~ de=100 manu=5 ch=0 dim=0: 1 -> 1.5 (+0.5)
+  ~ de=100 manu=5 ch=0 dim=1: 2 -> 2.5 (+0.5)
+  ~ de=100 manu=5 ch=1 dim=0: 2 -> 2.5 (+0.5)
+  ~ de=100 manu=5 ch=1 dim=1: 3 -> 3.5 (+0.5)
+`
+	if got := out.String(); got != want {
+		t.Fatalf("invalid report:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	r, err = diffEntries(a, newEntry(pa, 1, ma), 0)
+	if err != nil {
+		t.Fatalf("could not compare entries: %+v", err)
+	}
+	out.Reset()
+	r.print(&out)
+	if !r.empty() || out.String() != "--- \n+++ \nentries are identical\n" {
+		t.Fatalf("identical entries reported as different:\n%s", out.String())
+	}
+}
+
+func TestDiffPayloads(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		a, b   root.Object
+		tol    float64
+		classA string
+		classB string
+		want   []change
+	}{
+		{
+			name:   "same",
+			a:      rbase.NewObjString("v1"),
+			b:      rbase.NewObjString("v1"),
+			classA: "TObjString", classB: "TObjString",
+			want: []change{},
+		},
+		{
+			name:   "string",
+			a:      rbase.NewObjString("v1"),
+			b:      rbase.NewObjString("v2"),
+			classA: "TObjString", classB: "TObjString",
+			want: []change{{Field: "/fields/str", A: "v1", B: "v2"}},
+		},
+		{
+			name:   "param-tolerance",
+			a:      newParam(100, 1, 1, 2, 1),
+			b:      newParam(100, 1, 1, 2, 1.05),
+			tol:    0.1,
+			classA: "AliMUONCalibParamND", classB: "AliMUONCalibParamND",
+			want: []change{},
+		},
+		{
+			name:   "param",
+			a:      newParam(100, 1, 1, 2, 1),
+			b:      newParam(100, 1, 1, 2, 1.5),
+			tol:    0.1,
+			classA: "AliMUONCalibParamND", classB: "AliMUONCalibParamND",
+			want: []change{
+				{Field: "/values/0/0", A: 1.0, B: 1.5},
+				{Field: "/values/0/1", A: 2.0, B: 2.5},
+			},
+		},
+		{
+			name:   "nil",
+			a:      nil,
+			b:      rbase.NewObjString("v1"),
+			classA: "", classB: "TObjString",
+			want: []change{{Field: "", A: nil, B: map[string]interface{}{
+				"class":  "TObjString",
+				"fields": map[string]interface{}{"str": "v1"},
+			}}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := diffPayloads(tc.a, tc.b, tc.tol)
+			if err != nil {
+				t.Fatalf("could not compare payloads: %+v", err)
+			}
+			if d.ClassA != tc.classA || d.ClassB != tc.classB {
+				t.Fatalf("invalid classes: got=(%q, %q), want=(%q, %q)", d.ClassA, d.ClassB, tc.classA, tc.classB)
+			}
+			if !reflect.DeepEqual(d.Changes, tc.want) {
+				t.Fatalf("invalid changes:\ngot= %#v\nwant=%#v", d.Changes, tc.want)
+			}
+		})
+	}
+}
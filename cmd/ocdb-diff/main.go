@@ -0,0 +1,145 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-diff compares two OCDB entries: their ID, metadata,
+// properties and payload.
+//
+// AliMUON2DMap payloads are compared manu by manu, reporting added and
+// removed manus and the channel values that changed by more than a tolerance.
+// Other payloads are compared field by field.
+//
+// ocdb-diff exits with status 1 if the entries differ.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	_ "github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	_ "go-hep.org/x/hep/groot/ztypes"
+)
+
+func main() {
+	log.SetPrefix("ocdb-diff: ")
+	log.SetFlags(0)
+
+	var (
+		tol    = flag.Float64("tol", 0, "tolerance on payload values")
+		doJSON = flag.Bool("json", false, "print the differences as JSON")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-diff [options] a.root b.root\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	a, err := ocdb.ReadEntry(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	b, err := ocdb.ReadEntry(flag.Arg(1))
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	r, err := diffEntries(a, b, *tol)
+	if err != nil {
+		log.Fatalf("could not compare entries: %+v", err)
+	}
+	r.A = flag.Arg(0)
+	r.B = flag.Arg(1)
+
+	if *doJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		r.print(os.Stdout)
+	}
+
+	if !r.empty() {
+		os.Exit(1)
+	}
+}
+
+func (r *report) print(w io.Writer) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", r.A, r.B)
+	if r.empty() {
+		fmt.Fprintf(w, "entries are identical\n")
+		return
+	}
+
+	printChanges(w, "ID", r.ID)
+	printChanges(w, "MetaData", r.MetaData)
+	printChanges(w, "Properties", r.Properties)
+
+	p := r.Payload
+	if p == nil {
+		return
+	}
+	if p.ClassA != p.ClassB {
+		fmt.Fprintf(w, "Payload: class %q -> %q\n", p.ClassA, p.ClassB)
+	}
+	printChanges(w, "Payload", p.Changes)
+
+	m := p.Manus
+	if m == nil || m.empty() {
+		return
+	}
+	fmt.Fprintf(w, "Payload (%s):\n", p.ClassA)
+	for _, v := range m.Removed {
+		fmt.Fprintf(w, "  - manu (de=%d, manu=%d)\n", v.DE, v.Manu)
+	}
+	for _, v := range m.Added {
+		fmt.Fprintf(w, "  + manu (de=%d, manu=%d)\n", v.DE, v.Manu)
+	}
+	for _, v := range m.Reshaped {
+		fmt.Fprintf(w, "  ~ manu (de=%d, manu=%d): number of channels or dimension changed\n", v.DE, v.Manu)
+	}
+	for _, v := range m.Objects {
+		if v.ClassA != v.ClassB {
+			fmt.Fprintf(w, "  ~ manu (de=%d, manu=%d): class %q -> %q\n", v.DE, v.Manu, v.ClassA, v.ClassB)
+			continue
+		}
+		for _, c := range v.Changes {
+			fmt.Fprintf(w, "  ~ manu (de=%d, manu=%d) %s: %v -> %v\n", v.DE, v.Manu, c.Field, c.A, c.B)
+		}
+	}
+	for _, v := range m.Deltas {
+		fmt.Fprintf(w, "  ~ de=%d manu=%d ch=%d dim=%d: %g -> %g (%+g)\n",
+			v.DE, v.Manu, v.Channel, v.Dim, v.A, v.B, v.Delta,
+		)
+	}
+}
+
+func printChanges(w io.Writer, title string, cs []change) {
+	if len(cs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, c := range cs {
+		switch {
+		case c.A == nil:
+			fmt.Fprintf(w, "  + %s: %v\n", c.Field, c.B)
+		case c.B == nil:
+			fmt.Fprintf(w, "  - %s: %v\n", c.Field, c.A)
+		default:
+			fmt.Fprintf(w, "  ~ %s: %v -> %v\n", c.Field, c.A, c.B)
+		}
+	}
+}
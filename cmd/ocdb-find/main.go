@@ -0,0 +1,152 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-find searches the entries of a local OCDB storage by their
+// metadata, with ocdb.Filter expressions such as:
+//
+//	class=AliMUON2DMap responsible~"MUON" comment~Occupancy prop:RunUsed=297624
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	_ "github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	"github.com/pkg/errors"
+	_ "go-hep.org/x/hep/groot/ztypes"
+)
+
+// match is an entry matching the filter.
+type match struct {
+	File     string         `json:"file"`
+	ID       ocdb.ID        `json:"id"`
+	MetaData *ocdb.MetaData `json:"metadata"`
+}
+
+func main() {
+	log.SetPrefix("ocdb-find: ")
+	log.SetFlags(0)
+
+	var (
+		pattern = flag.String("path", "*/*/*", "only search paths matching this pattern")
+		doJSON  = flag.Bool("json", false, "print matching entries as JSON")
+		index   = flag.String("index", "", "index file of the storage (default: <dir>/"+ocdb.IndexFile+", if it exists)")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-find [options] storage-dir [expr...]\n")
+		fmt.Fprintf(os.Stderr, "\nExpressions are of the form field=value or field~regexp, with fields:\n")
+		fmt.Fprintf(os.Stderr, "class, responsible, comment, aliroot or prop:<key>.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	filter, err := ocdb.ParseFilter(flag.Args()[1:]...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := ocdb.NewLocal(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	err = db.LoadIndex(*index)
+	switch {
+	case errors.Cause(err) == ocdb.ErrStaleIndex:
+		log.Printf("%v: scanning directories instead", err)
+	case err != nil:
+		log.Fatalf("%+v", err)
+	}
+
+	var (
+		ms    = []match{}
+		nerrs = 0
+	)
+	switch idx := db.Index(); idx {
+	case nil:
+		ids, err := db.List(*pattern)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		for _, id := range ids {
+			entry, err := db.Load(id)
+			if err != nil {
+				log.Printf("error: %v", err)
+				nerrs++
+				continue
+			}
+			if filter.Match(entry.MetaData()) {
+				ms = append(ms, match{File: db.Filename(id), ID: id, MetaData: entry.MetaData()})
+			}
+		}
+	default:
+		p, err := ocdb.ParsePath(*pattern)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		for _, e := range idx.Entries() {
+			id := e.ID()
+			if !p.Comprises(id.Path()) {
+				continue
+			}
+			if e.Err != "" {
+				log.Printf("error: %s", e.Err)
+				nerrs++
+				continue
+			}
+			if !filter.MatchIndex(e) {
+				continue
+			}
+			ms = append(ms, match{File: db.Filename(id), ID: id, MetaData: metaData(e)})
+		}
+	}
+
+	if *doJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(ms)
+	} else {
+		err = printText(os.Stdout, ms)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if nerrs > 0 {
+		os.Exit(1)
+	}
+}
+
+// metaData returns the metadata of an indexed entry.
+func metaData(e ocdb.IndexEntry) *ocdb.MetaData {
+	meta := ocdb.NewMetaData(e.Responsible, e.BeamPeriod, e.AliRootVersion, e.Comment)
+	meta.SetObjectClassName(e.ObjectClassName)
+	for k, v := range e.Properties {
+		meta.SetProperty(k, v)
+	}
+	return meta
+}
+
+func printText(w io.Writer, ms []match) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, m := range ms {
+		var class, resp, comment string
+		if meta := m.MetaData; meta != nil {
+			class, resp, comment = meta.ObjectClassName(), meta.Responsible(), meta.Comment()
+		}
+		fmt.Fprintf(tw, "%s\t%s\tresponsible=%q\tcomment=%q\n", m.File, class, resp, comment)
+	}
+	return tw.Flush()
+}
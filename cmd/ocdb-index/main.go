@@ -0,0 +1,113 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-index builds the index of a local OCDB tree.
+//
+// The index holds the ID, size, modification time, payload class and
+// metadata of all the files of the tree, so they can be looked up without
+// scanning directories nor opening files.
+// An existing index is updated incrementally: only new and modified files are read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	_ "go-hep.org/x/hep/groot/ztypes"
+)
+
+func main() {
+	log.SetPrefix("ocdb-index: ")
+	log.SetFlags(0)
+
+	var (
+		oname = flag.String("o", "", "index file (default: <dir>/"+ocdb.IndexFile+")")
+		full  = flag.Bool("full", false, "rebuild the index from scratch")
+		list  = flag.Bool("ls", false, "list the indexed files")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-index [options] dir\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir, err := filepath.Abs(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !fi.IsDir() {
+		log.Fatalf("%q is not a directory", dir)
+	}
+
+	fname := *oname
+	if fname == "" {
+		fname = filepath.Join(dir, ocdb.IndexFile)
+	}
+
+	idx := ocdb.NewIndex(dir)
+	if _, err := os.Stat(fname); err == nil && !*full {
+		prev, err := ocdb.ReadIndex(fname, dir)
+		switch {
+		case err != nil:
+			log.Printf("could not read index, rebuilding it: %v", err)
+		default:
+			idx = prev
+		}
+	}
+
+	start := time.Now()
+	stats, err := idx.Update()
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	for _, err := range stats.Errors {
+		log.Printf("error: %v", err)
+	}
+
+	err = idx.Write(fname)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	log.Printf(
+		"%d files indexed in %v (%d added, %d updated, %d removed, %d unchanged, %d errors)",
+		len(idx.Entries()), time.Since(start).Round(time.Millisecond),
+		stats.Added, stats.Updated, stats.Removed, stats.Unchanged, len(stats.Errors),
+	)
+
+	if *list {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(tw, "PATH\tRUNS\tVERSION\tSIZE\tCLASS\tRESPONSIBLE\n")
+		for _, e := range idx.Entries() {
+			fmt.Fprintf(tw, "%s\t[%d, %d]\tv%d_s%d\t%d\t%s\t%s\n",
+				e.Path, e.First, e.Last, e.Version, e.SubVersion, e.Size, e.Class, e.Responsible,
+			)
+		}
+		err = tw.Flush()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if len(stats.Errors) > 0 {
+		os.Exit(1)
+	}
+}
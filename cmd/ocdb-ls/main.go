@@ -2,37 +2,286 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+// Command ocdb-ls lists the content of OCDB files.
+//
+// Files may be given as file names, directories (searched recursively for
+// ".root" files) or glob patterns.
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	_ "github.com/alice-go/aligo/muon/muoncalib"
 	"github.com/alice-go/aligo/ocdb"
+	"github.com/pkg/errors"
 	"go-hep.org/x/hep/groot"
 	_ "go-hep.org/x/hep/groot/ztypes"
 )
 
+// item is an entry read from a key of an OCDB file.
+type item struct {
+	File  string      `json:"file"`
+	Key   string      `json:"key"`
+	Cycle int         `json:"cycle"`
+	Entry *ocdb.Entry `json:"-"`
+}
+
 func main() {
+	log.SetPrefix("ocdb-ls: ")
+	log.SetFlags(0)
+
+	var (
+		key     = flag.String("key", "", "only list keys matching this pattern (e.g. \"AliCDB*\")")
+		cycle   = flag.Int("cycle", 0, "cycle of the keys to list (0: latest cycle of each key, -1: all cycles)")
+		format  = flag.String("format", "text", "output format (text, json, table)")
+		payload = flag.String("payload", "summary", "how to display payloads (none, summary, full)")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-ls [options] file-dir-or-glob [file-dir-or-glob...]\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	f, err := groot.Open(flag.Arg(0))
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch *format {
+	case "text", "json", "table":
+	default:
+		log.Fatalf("invalid format %q", *format)
+	}
+	switch *payload {
+	case "none", "summary", "full":
+	default:
+		log.Fatalf("invalid payload mode %q", *payload)
+	}
+
+	fnames, err := files(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		items []item
+		nerrs int
+	)
+	for _, fname := range fnames {
+		vs, err := read(fname, *key, *cycle)
+		if err != nil {
+			log.Printf("error: %v", err)
+			nerrs++
+		}
+		items = append(items, vs...)
+	}
+
+	switch *format {
+	case "text":
+		for _, it := range items {
+			printText(os.Stdout, it, *payload)
+		}
+	case "json":
+		err = printJSON(os.Stdout, items, *payload)
+	case "table":
+		err = printTable(os.Stdout, items)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if nerrs > 0 {
+		os.Exit(1)
+	}
+}
+
+// files expands the provided arguments into a sorted list of file names.
+func files(args []string) ([]string, error) {
+	var fnames []string
+	for _, arg := range args {
+		names := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			vs, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid glob pattern %q", arg)
+			}
+			if len(vs) == 0 {
+				log.Printf("warning: no file matching %q", arg)
+			}
+			names = vs
+		}
+
+		for _, name := range names {
+			fi, err := os.Stat(name)
+			if err != nil {
+				return nil, err
+			}
+			if !fi.IsDir() {
+				fnames = append(fnames, name)
+				continue
+			}
+			err = filepath.Walk(name, func(fname string, fi os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !fi.IsDir() && filepath.Ext(fname) == ".root" {
+					fnames = append(fnames, fname)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(fnames)
+	return fnames, nil
+}
+
+// read reads the entries stored in the selected keys of the named file.
+// Keys that do not hold an entry are reported as warnings.
+func read(fname, key string, cycle int) ([]item, error) {
+	f, err := groot.Open(fname)
+	if err != nil {
+		return nil, err
+	}
 	defer f.Close()
 
+	latest := make(map[string]int)
+	for _, k := range f.Keys() {
+		if k.Cycle() > latest[k.Name()] {
+			latest[k.Name()] = k.Cycle()
+		}
+	}
+
+	var items []item
 	for _, k := range f.Keys() {
-		log.Printf("key: %v", k.Name())
+		if key != "" {
+			ok, err := path.Match(key, k.Name())
+			if err != nil {
+				return items, errors.Wrapf(err, "invalid key pattern %q", key)
+			}
+			if !ok {
+				continue
+			}
+		}
+		switch {
+		case cycle == 0 && k.Cycle() != latest[k.Name()]:
+			continue
+		case cycle > 0 && k.Cycle() != cycle:
+			continue
+		}
 
-		o, err := f.Get(k.Name())
+		if k.ClassName() != "AliCDBEntry" {
+			log.Printf("warning: %s: key %s;%d is a %s, not an AliCDBEntry", fname, k.Name(), k.Cycle(), k.ClassName())
+			continue
+		}
+
+		o, err := k.Object()
 		if err != nil {
-			log.Fatal(err)
+			return items, errors.Wrapf(err, "%s: could not read key %s;%d", fname, k.Name(), k.Cycle())
+		}
+		entry, ok := o.(*ocdb.Entry)
+		if !ok {
+			log.Printf("warning: %s: key %s;%d is a %T, not an AliCDBEntry", fname, k.Name(), k.Cycle(), o)
+			continue
+		}
+		items = append(items, item{File: fname, Key: k.Name(), Cycle: k.Cycle(), Entry: entry})
+	}
+	return items, nil
+}
+
+func objectClass(entry *ocdb.Entry) string {
+	if entry.Object() == nil {
+		return "<nil>"
+	}
+	return entry.Object().Class()
+}
+
+func printText(w io.Writer, it item, payload string) {
+	entry := it.Entry
+	fmt.Fprintf(w, "=== %s [%s;%d] ===\nID: %v\nOwner: %v\n", it.File, it.Key, it.Cycle, entry.Id(), entry.IsOwner())
+	if meta := entry.MetaData(); meta != nil {
+		fmt.Fprintf(w, "MetaData:\n")
+		meta.Display(w)
+	}
+	if payload != "none" {
+		fmt.Fprintf(w, "Object: %s\n", objectClass(entry))
+		ocdb.DisplayObject(w, entry.Object(), payload == "full")
+	}
+	fmt.Fprintf(w, "===\n")
+}
+
+func printJSON(w io.Writer, items []item, payload string) error {
+	type summary struct {
+		Class   string `json:"class"`
+		Summary string `json:"summary"` // as displayed by the text output
+	}
+	type jsonItem struct {
+		item
+		ID       ocdb.ID        `json:"id"`
+		MetaData *ocdb.MetaData `json:"metadata"`
+		Owner    bool           `json:"owner"`
+		Object   interface{}    `json:"object,omitempty"`
+	}
+
+	vs := make([]jsonItem, len(items))
+	for i, it := range items {
+		v := jsonItem{
+			item:     it,
+			ID:       it.Entry.Id(),
+			MetaData: it.Entry.MetaData(),
+			Owner:    it.Entry.IsOwner(),
 		}
+		switch payload {
+		case "summary":
+			buf := new(bytes.Buffer)
+			ocdb.DisplayObject(buf, it.Entry.Object(), false)
+			v.Object = summary{
+				Class:   objectClass(it.Entry),
+				Summary: strings.TrimRight(buf.String(), "\n"),
+			}
+		case "full":
+			raw, err := ocdb.MarshalObject(it.Entry.Object())
+			if err != nil {
+				return errors.Wrapf(err, "%s: could not encode payload", it.File)
+			}
+			v.Object = json.RawMessage(raw)
+		}
+		vs[i] = v
+	}
 
-		v := o.(*ocdb.Entry)
-		v.Display(os.Stdout)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(vs)
+}
+
+func printTable(w io.Writer, items []item) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "FILE\tKEY\tPATH\tRUNS\tVERSION\tCLASS\tRESPONSIBLE\n")
+	for _, it := range items {
+		id := it.Entry.Id()
+		resp := ""
+		if meta := it.Entry.MetaData(); meta != nil {
+			resp = meta.Responsible()
+		}
+		fmt.Fprintf(tw, "%s\t%s;%d\t%s\t[%d, %d]\tv%d_s%d\t%s\t%s\n",
+			it.File, it.Key, it.Cycle, id.Path().Name(),
+			id.Runs().First, id.Runs().Last, id.Version(), id.SubVersion(),
+			objectClass(it.Entry), resp,
+		)
 	}
+	return tw.Flush()
 }
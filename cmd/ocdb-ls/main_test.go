@@ -0,0 +1,273 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot"
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/riofs"
+	"go-hep.org/x/hep/groot/root"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func newEntry(path string, first, last int32, obj root.Object) *ocdb.Entry {
+	p, err := ocdb.ParsePath(path)
+	if err != nil {
+		panic(err)
+	}
+	meta := ocdb.NewMetaData("MUON TRK", 0, "v5-09-38", "test entry")
+	meta.SetProperty("RunUsed", "297624")
+	return ocdb.NewEntry(obj, ocdb.NewID(p, ocdb.NewRunRange(first, last), 1, 0), meta, true)
+}
+
+func testMap() *muoncalib.Map2D {
+	m := muoncalib.NewMap2D(true)
+	for _, manu := range []muoncalib.Manu{{DeID: 100, ID: 1}, {DeID: 100, ID: 2}} {
+		p := muoncalib.NewParamND(1, 2, uint32(manu.DeID), uint32(manu.ID))
+		for i := 0; i < p.Size(); i++ {
+			p.SetValue(i, 0, float64(100*manu.ID+i))
+		}
+		m.Add(manu.DeID, manu.ID, p)
+	}
+	return m
+}
+
+// writeFile writes objects under the provided keys of the named file.
+// Keys written several times are given increasing cycles.
+func writeFile(t *testing.T, fname string, keys []string, objs []root.Object) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(fname), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := groot.Create(fname, riofs.WithoutCompression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for i, key := range keys {
+		err = f.Put(key, objs[i])
+		if err != nil {
+			t.Fatalf("could not write key %q: %+v", key, err)
+		}
+	}
+	err = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestFiles creates a directory holding:
+//   - MUON/Calib/Pedestals/Run1_10_v1_s0.root, with an AliMUON2DMap entry,
+//   - MUON/Calib/Gains/Run5_5_v1_s0.root, with 2 cycles of an entry and a TObjString.
+func newTestFiles(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ocdb-ls-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, "MUON/Calib/Pedestals/Run1_10_v1_s0.root"),
+		[]string{ocdb.EntryKey},
+		[]root.Object{newEntry("MUON/Calib/Pedestals", 1, 10, testMap())},
+	)
+	writeFile(t, filepath.Join(dir, "MUON/Calib/Gains/Run5_5_v1_s0.root"),
+		[]string{ocdb.EntryKey, ocdb.EntryKey, "comment"},
+		[]root.Object{
+			newEntry("MUON/Calib/Gains", 5, 5, rbase.NewObjString("v1")),
+			newEntry("MUON/Calib/Gains", 5, 5, rbase.NewObjString("v2")),
+			rbase.NewObjString("not an entry"),
+		},
+	)
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// readAll reads the selected keys of the files found under dir,
+// and makes the file names of the items relative to dir, with slashes.
+func readAll(t *testing.T, dir, key string, cycle int) []item {
+	t.Helper()
+	fnames, err := files([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []item
+	for _, fname := range fnames {
+		vs, err := read(fname, key, cycle)
+		if err != nil {
+			t.Fatalf("could not read %q: %+v", fname, err)
+		}
+		for i := range vs {
+			rel, err := filepath.Rel(dir, vs[i].File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vs[i].File = filepath.ToSlash(rel)
+		}
+		items = append(items, vs...)
+	}
+	return items
+}
+
+// checkGolden compares got with the content of the named golden file,
+// or updates that file.
+func checkGolden(t *testing.T, got []byte, fname string) {
+	t.Helper()
+	fname = filepath.Join("testdata", fname)
+	if *update {
+		err := ioutil.WriteFile(fname, got, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("invalid output:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir, cleanup := newTestFiles(t)
+	defer cleanup()
+
+	var (
+		gains = filepath.Join(dir, "MUON/Calib/Gains/Run5_5_v1_s0.root")
+		peds  = filepath.Join(dir, "MUON/Calib/Pedestals/Run1_10_v1_s0.root")
+	)
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{dir}, []string{gains, peds}},
+		{[]string{peds, filepath.Join(dir, "MUON/Calib/Gains")}, []string{gains, peds}},
+		{[]string{filepath.Join(dir, "MUON/Calib/*/Run1_*.root")}, []string{peds}},
+		{[]string{filepath.Join(dir, "TPC/*")}, nil},
+	} {
+		got, err := files(tc.args)
+		if err != nil {
+			t.Fatalf("%q: could not expand arguments: %+v", tc.args, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%q: invalid files:\ngot= %q\nwant=%q", tc.args, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("%q: invalid files:\ngot= %q\nwant=%q", tc.args, got, tc.want)
+			}
+		}
+	}
+
+	if _, err := files([]string{filepath.Join(dir, "missing.root")}); err == nil {
+		t.Fatalf("expected an error for a missing file")
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir, cleanup := newTestFiles(t)
+	defer cleanup()
+
+	type want struct {
+		file  string
+		cycle int
+		obj   string
+	}
+	var (
+		gains = "MUON/Calib/Gains/Run5_5_v1_s0.root"
+		peds  = "MUON/Calib/Pedestals/Run1_10_v1_s0.root"
+	)
+	for _, tc := range []struct {
+		key   string
+		cycle int
+		want  []want
+	}{
+		{"", 0, []want{{gains, 2, "v2"}, {peds, 1, "AliMUON2DMap"}}},
+		{"", -1, []want{{gains, 1, "v1"}, {gains, 2, "v2"}, {peds, 1, "AliMUON2DMap"}}},
+		{"", 1, []want{{gains, 1, "v1"}, {peds, 1, "AliMUON2DMap"}}},
+		{"AliCDB*", 2, []want{{gains, 2, "v2"}}},
+		{"comment", -1, nil},
+	} {
+		items := readAll(t, dir, tc.key, tc.cycle)
+		if len(items) != len(tc.want) {
+			t.Fatalf("key=%q cycle=%d: got %d items, want %d", tc.key, tc.cycle, len(items), len(tc.want))
+		}
+		for i, it := range items {
+			obj := it.Entry.Object().Class()
+			if s, ok := it.Entry.Object().(*rbase.ObjString); ok {
+				obj = s.String()
+			}
+			got := want{it.File, it.Cycle, obj}
+			if got != tc.want[i] || it.Key != ocdb.EntryKey {
+				t.Fatalf("key=%q cycle=%d: invalid item %d: got=%v (key %q), want=%v",
+					tc.key, tc.cycle, i, got, it.Key, tc.want[i],
+				)
+			}
+		}
+	}
+
+	if _, err := read(filepath.Join(dir, gains), "[", 0); err == nil {
+		t.Fatalf("expected an error for an invalid key pattern")
+	}
+}
+
+func TestPrint(t *testing.T) {
+	dir, cleanup := newTestFiles(t)
+	defer cleanup()
+
+	items := readAll(t, dir, "", 0)
+	for _, tc := range []struct {
+		name   string
+		print  func(buf *bytes.Buffer) error
+		golden string
+	}{
+		{"text", func(buf *bytes.Buffer) error {
+			for _, it := range items {
+				printText(buf, it, "summary")
+			}
+			return nil
+		}, "text.txt"},
+		{"text-none", func(buf *bytes.Buffer) error {
+			printText(buf, items[0], "none")
+			return nil
+		}, "text-none.txt"},
+		{"text-full", func(buf *bytes.Buffer) error {
+			printText(buf, items[1], "full")
+			return nil
+		}, "text-full.txt"},
+		{"json", func(buf *bytes.Buffer) error {
+			return printJSON(buf, items, "summary")
+		}, "summary.json"},
+		{"json-none", func(buf *bytes.Buffer) error {
+			return printJSON(buf, items, "none")
+		}, "none.json"},
+		{"json-full", func(buf *bytes.Buffer) error {
+			return printJSON(buf, items, "full")
+		}, "full.json"},
+		{"table", func(buf *bytes.Buffer) error {
+			return printTable(buf, items)
+		}, "table.txt"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tc.print(&buf)
+			if err != nil {
+				t.Fatalf("could not print items: %+v", err)
+			}
+			checkGolden(t, buf.Bytes(), tc.golden)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-manifest creates and verifies SHA-256 manifests of OCDB trees.
+//
+// A manifest lists, for every ".root" file of a tree, its checksum, its size
+// and the ID of the entry it holds.
+// Verifying a tree against a manifest reports missing, extra and modified files,
+// as well as files that can not be decoded anymore.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	_ "github.com/alice-go/aligo/muon/muoncalib"
+	_ "go-hep.org/x/hep/groot/ztypes"
+)
+
+// manifestFile is the default name of the manifest of a tree, at its top.
+const manifestFile = "ocdb.manifest"
+
+func main() {
+	log.SetPrefix("ocdb-manifest: ")
+	log.SetFlags(0)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-manifest create|verify [options] dir\n")
+		fmt.Fprintf(os.Stderr, "Run 'ocdb-manifest create -h' or 'ocdb-manifest verify -h' for the options.\n")
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		cmd   = flag.Arg(0)
+		fset  = flag.NewFlagSet(cmd, flag.ExitOnError)
+		mname = fset.String("m", "", "manifest file (default: <dir>/"+manifestFile+")")
+		njobs = fset.Int("j", runtime.NumCPU(), "number of files processed concurrently")
+	)
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-manifest %s [options] dir\n", cmd)
+		fset.PrintDefaults()
+	}
+
+	switch cmd {
+	case "create", "verify":
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fset.Parse(flag.Args()[1:])
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(2)
+	}
+	if *njobs < 1 {
+		log.Fatalf("invalid number of jobs %d", *njobs)
+	}
+
+	dir := fset.Arg(0)
+	if *mname == "" {
+		*mname = filepath.Join(dir, manifestFile)
+	}
+
+	var err error
+	switch cmd {
+	case "create":
+		err = create(dir, *mname, *njobs)
+	case "verify":
+		err = verify(os.Stdout, dir, *mname, *njobs)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func create(dir, mname string, n int) error {
+	fnames, err := files(dir)
+	if err != nil {
+		return err
+	}
+
+	recs := scan(dir, fnames, n)
+	nerrs := 0
+	for _, rec := range recs {
+		switch {
+		case rec.Sum == "":
+			return fmt.Errorf("could not checksum %s: %v", rec.File, rec.Err)
+		case rec.Err != nil:
+			log.Printf("warning: %s: could not decode entry: %v", rec.File, rec.Err)
+			nerrs++
+		}
+	}
+
+	f, err := os.Create(mname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = writeManifest(f, recs)
+	if err != nil {
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%d files written to %s (%d undecodable)", len(recs), mname, nerrs)
+	return nil
+}
+
+// verify checks the files of dir against the named manifest, writing to w
+// the files that are not ok.
+// verify returns an error if any file is not ok.
+func verify(w io.Writer, dir, mname string, n int) error {
+	f, err := os.Open(mname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	want, err := readManifest(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", mname, err)
+	}
+
+	fnames, err := files(dir)
+	if err != nil {
+		return err
+	}
+
+	var (
+		stats = make(map[string]int)
+		got   = make(map[string]bool)
+		todo  []string // files both in the tree and the manifest
+	)
+	report := func(status, file, msg string) {
+		stats[status]++
+		if msg != "" {
+			msg = ": " + msg
+		}
+		fmt.Fprintf(w, "%s: %s%s\n", status, file, msg)
+	}
+
+	for _, fname := range fnames {
+		got[fname] = true
+		if _, ok := want[fname]; !ok {
+			report("extra", fname, "")
+			continue
+		}
+		todo = append(todo, fname)
+	}
+	for _, fname := range sortedKeys(want) {
+		if !got[fname] {
+			report("missing", fname, "")
+		}
+	}
+
+	for _, rec := range scan(dir, todo, n) {
+		status, msg := check(rec, want[rec.File])
+		switch {
+		case status != "ok":
+			report(status, rec.File, msg)
+		case rec.Err != nil:
+			// already undecodable when the manifest was created: reported, but ok.
+			report("ok", rec.File, "undecodable as in manifest: "+rec.Err.Error())
+		default:
+			stats["ok"]++
+		}
+	}
+
+	log.Printf(
+		"%d ok, %d missing, %d extra, %d modified, %d unreadable, %d undecodable",
+		stats["ok"], stats["missing"], stats["extra"], stats["modified"], stats["unreadable"], stats["undecodable"],
+	)
+	nbad := 0
+	for _, status := range []string{"missing", "extra", "modified", "unreadable", "undecodable"} {
+		nbad += stats[status]
+	}
+	if nbad > 0 {
+		return fmt.Errorf("%d files failed verification", nbad)
+	}
+	return nil
+}
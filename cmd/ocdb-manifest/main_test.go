@@ -0,0 +1,149 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+// newTestTree creates a tree holding 3 entries and an undecodable file,
+// and its manifest.
+func newTestTree(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ocdb-manifest-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ocdb.NewLocal(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	for _, path := range []string{"GRP/GRP/Data", "MUON/Calib/Gains", "MUON/Calib/Pedestals"} {
+		p, err := ocdb.ParsePath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := ocdb.NewID(p, ocdb.NewRunRange(0, 100), -1, -1)
+		_, err = db.Put(ocdb.NewEntry(rbase.NewObjString(path), id, nil, true))
+		if err != nil {
+			t.Fatalf("could not store entry: %+v", err)
+		}
+	}
+	write(t, filepath.Join(dir, "MUON/Calib/Bad/Run0_10_v1_s0.root"), "not a ROOT file")
+
+	err = create(dir, filepath.Join(dir, manifestFile), 2)
+	if err != nil {
+		t.Fatalf("could not create manifest: %+v", err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func write(t *testing.T, fname, content string) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(fname), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(fname, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const (
+		bad     = "MUON/Calib/Bad/Run0_10_v1_s0.root"
+		gains   = "MUON/Calib/Gains/Run0_100_v1_s0.root"
+		peds    = "MUON/Calib/Pedestals/Run0_100_v1_s0.root"
+		extra   = "MUON/Calib/Gains/Run0_100_v2_s0.root"
+		badLine = "ok: " + bad + ": undecodable as in manifest: "
+	)
+
+	for _, tc := range []struct {
+		name   string
+		modify func(t *testing.T, dir string)
+		want   []string // prefixes of the reported lines
+		err    bool
+	}{
+		{
+			name: "unchanged",
+			want: []string{badLine},
+		},
+		{
+			name: "modified",
+			modify: func(t *testing.T, dir string) {
+				write(t, filepath.Join(dir, peds), "modified")
+			},
+			want: []string{badLine, "modified: " + peds + ": sha256 "},
+			err:  true,
+		},
+		{
+			name: "missing-and-extra",
+			modify: func(t *testing.T, dir string) {
+				err := os.Rename(filepath.Join(dir, gains), filepath.Join(dir, extra))
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"extra: " + extra, "missing: " + gains, badLine},
+			err:  true,
+		},
+		{
+			// the manifest records an ID for a file that can not be decoded.
+			name: "no-longer-decodable",
+			modify: func(t *testing.T, dir string) {
+				fname := filepath.Join(dir, manifestFile)
+				raw, err := ioutil.ReadFile(fname)
+				if err != nil {
+					t.Fatal(err)
+				}
+				raw = bytes.Replace(raw, []byte(" - - - - - "+bad), []byte(" MUON/Calib/Bad 0 10 1 0 "+bad), 1)
+				write(t, fname, string(raw))
+			},
+			want: []string{"undecodable: " + bad + ": "},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, cleanup := newTestTree(t)
+			defer cleanup()
+			if tc.modify != nil {
+				tc.modify(t, dir)
+			}
+
+			var buf bytes.Buffer
+			err := verify(&buf, dir, filepath.Join(dir, manifestFile), 2)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected an error")
+			case !tc.err && err != nil:
+				t.Fatalf("could not verify tree: %+v", err)
+			}
+
+			lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+			if len(lines) != len(tc.want) {
+				t.Fatalf("invalid report:\ngot:\n%s\nwant lines starting with:\n%s", buf.String(), strings.Join(tc.want, "\n"))
+			}
+			for i, line := range lines {
+				if !strings.HasPrefix(line, tc.want[i]) {
+					t.Fatalf("invalid report line %d:\ngot= %q\nwant=%q...", i, line, tc.want[i])
+				}
+			}
+		})
+	}
+
+	if err := verify(ioutil.Discard, os.TempDir(), filepath.Join(os.TempDir(), "missing.manifest"), 1); err == nil {
+		t.Fatalf("expected an error for a missing manifest")
+	}
+}
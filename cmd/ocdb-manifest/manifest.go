@@ -0,0 +1,193 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alice-go/aligo/ocdb"
+	"github.com/pkg/errors"
+)
+
+// manifestHeader is the first line of a manifest.
+// The file name is the last field of a line, so that it may contain spaces.
+const manifestHeader = "# ocdb-manifest v2: sha256 size path first last version subversion file"
+
+// record describes a file of an OCDB tree.
+type record struct {
+	File string // file name, relative to the top directory of the tree
+	Sum  string // hex-encoded SHA-256 checksum
+	Size int64
+	ID   string // decoded entry ID, as "path first last version subversion"
+	Err  error  // error decoding the entry
+}
+
+func formatID(id ocdb.ID) string {
+	return fmt.Sprintf("%s %d %d %d %d",
+		id.Path().Name(), id.Runs().First, id.Runs().Last, id.Version(), id.SubVersion(),
+	)
+}
+
+// files returns the names of the ".root" files under dir, relative to dir, sorted.
+func files(dir string) ([]string, error) {
+	var fnames []string
+	err := filepath.Walk(dir, func(fname string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(fname) != ".root" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, fname)
+		if err != nil {
+			return err
+		}
+		fnames = append(fnames, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(fnames)
+	return fnames, err
+}
+
+// scan checksums and decodes the named files of dir, with n workers.
+// Records are returned in the order of fnames.
+func scan(dir string, fnames []string, n int) []record {
+	var (
+		recs = make([]record, len(fnames))
+		jobs = make(chan int)
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				recs[i] = newRecord(dir, fnames[i])
+			}
+		}()
+	}
+	for i := range fnames {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return recs
+}
+
+func newRecord(dir, rel string) record {
+	rec := record{File: rel}
+	fname := filepath.Join(dir, filepath.FromSlash(rel))
+
+	f, err := os.Open(fname)
+	if err != nil {
+		rec.Err = err
+		return rec
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	rec.Size, err = io.Copy(h, f)
+	if err != nil {
+		rec.Err = err
+		return rec
+	}
+	rec.Sum = hex.EncodeToString(h.Sum(nil))
+
+	entry, err := ocdb.ReadEntry(fname)
+	if err != nil {
+		rec.Err = err
+		return rec
+	}
+	rec.ID = formatID(entry.Id())
+	return rec
+}
+
+// writeManifest writes recs to w, one file per line.
+// Files that could not be decoded have a "-" ID.
+func writeManifest(w io.Writer, recs []record) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s\n", manifestHeader)
+	for _, rec := range recs {
+		id := rec.ID
+		if id == "" {
+			id = "- - - - -"
+		}
+		fmt.Fprintf(bw, "%s %d %s %s\n", rec.Sum, rec.Size, id, rec.File)
+	}
+	return bw.Flush()
+}
+
+// readManifest reads the records of a manifest, indexed by file name.
+func readManifest(r io.Reader) (map[string]record, error) {
+	recs := make(map[string]record)
+	sc := bufio.NewScanner(r)
+	for i := 1; sc.Scan(); i++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "# ocdb-manifest ") && line != manifestHeader:
+			return nil, errors.Errorf("line %d: unsupported manifest format %q", i, line)
+		case strings.TrimSpace(line) == "", strings.HasPrefix(line, "#"):
+			continue
+		}
+		// the file name is the last field, and may contain spaces.
+		toks := strings.SplitN(line, " ", 8)
+		if len(toks) != 8 || toks[7] == "" {
+			return nil, errors.Errorf("line %d: invalid manifest line %q", i, line)
+		}
+		size, err := strconv.ParseInt(toks[1], 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("line %d: invalid file size %q", i, toks[1])
+		}
+		rec := record{File: toks[7], Sum: toks[0], Size: size}
+		if toks[2] != "-" {
+			rec.ID = strings.Join(toks[2:7], " ")
+		}
+		recs[rec.File] = rec
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrapf(err, "could not read manifest")
+	}
+	return recs, nil
+}
+
+// check compares rec, the record of a file of the tree, with ref, its record
+// in the manifest, and returns the status of the file: "ok", "unreadable",
+// "modified" or "undecodable", with a message describing the failure.
+// Files are compared by checksum first. An unchanged file that can not be
+// decoded is undecodable, unless the manifest records it as undecodable too.
+func check(rec, ref record) (string, string) {
+	switch {
+	case rec.Sum == "":
+		return "unreadable", rec.Err.Error()
+	case rec.Sum != ref.Sum:
+		return "modified", fmt.Sprintf("sha256 %s, want %s", rec.Sum, ref.Sum)
+	case rec.Size != ref.Size:
+		return "modified", fmt.Sprintf("size %d, want %d", rec.Size, ref.Size)
+	case rec.Err != nil && ref.ID != "":
+		return "undecodable", rec.Err.Error()
+	case rec.Err == nil && ref.ID != "" && rec.ID != ref.ID:
+		return "modified", fmt.Sprintf("ID %q, want %q", rec.ID, ref.ID)
+	}
+	return "ok", ""
+}
+
+func sortedKeys(recs map[string]record) []string {
+	keys := make([]string, 0, len(recs))
+	for k := range recs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	recs := []record{
+		{
+			File: "GRP/GRP/Data/Run0_100_v1_s0.root",
+			Sum:  strings.Repeat("0b", 32),
+			Size: 5633,
+			ID:   "GRP/GRP/Data 0 100 1 0",
+		},
+		{
+			File: "MUON/Calib/Test Dir/Run1_10_v1_s0 copy.root",
+			Sum:  strings.Repeat("a8", 32),
+			Size: 42,
+			ID:   "MUON/Calib/Test 1 10 1 0",
+		},
+		{
+			File: "MUON/Calib/Test/ Run5_20_v2_s0.root ",
+			Sum:  strings.Repeat("7f", 32),
+			Size: 12,
+			Err:  errors.New("could not decode"),
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	err := writeManifest(buf, recs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readManifest(buf)
+	if err != nil {
+		t.Fatalf("could not read manifest: %+v\n%s", err, buf.String())
+	}
+	if len(got) != len(recs) {
+		t.Fatalf("invalid number of records: got=%d, want=%d", len(got), len(recs))
+	}
+	for _, want := range recs {
+		want.Err = nil
+		if !reflect.DeepEqual(got[want.File], want) {
+			t.Fatalf("invalid record:\ngot= %#v\nwant=%#v", got[want.File], want)
+		}
+	}
+}
+
+func TestReadManifestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		txt  string
+	}{
+		{"old-format", "# ocdb-manifest v1: sha256 size file path first last version subversion\n"},
+		{"short-line", manifestHeader + "\nabcd 12 GRP/GRP/Data 0 100 1 0\n"},
+		{"invalid-size", manifestHeader + "\nabcd 1x2 GRP/GRP/Data 0 100 1 0 Run0_100_v1_s0.root\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readManifest(strings.NewReader(tc.txt))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var (
+		decErr = errors.New("could not decode")
+		ref    = record{File: "f.root", Sum: "aa", Size: 10, ID: "A/B/C 0 1 1 0"}
+		bad    = record{File: "f.root", Sum: "aa", Size: 10}
+	)
+	for _, tc := range []struct {
+		name   string
+		rec    record
+		ref    record
+		status string
+	}{
+		{"ok", record{Sum: "aa", Size: 10, ID: "A/B/C 0 1 1 0"}, ref, "ok"},
+		{"unreadable", record{Err: decErr}, ref, "unreadable"},
+		{"checksum", record{Sum: "bb", Size: 10, ID: "A/B/C 0 1 1 0"}, ref, "modified"},
+		{"checksum-undecodable", record{Sum: "bb", Size: 10, Err: decErr}, ref, "modified"},
+		{"size", record{Sum: "aa", Size: 11, ID: "A/B/C 0 1 1 0"}, ref, "modified"},
+		{"id", record{Sum: "aa", Size: 10, ID: "A/B/C 0 2 1 0"}, ref, "modified"},
+		{"unchanged-undecodable", record{Sum: "aa", Size: 10, Err: decErr}, bad, "ok"},
+		{"unchanged-no-longer-decodable", record{Sum: "aa", Size: 10, Err: decErr}, ref, "undecodable"},
+		{"unchanged-now-decodable", record{Sum: "aa", Size: 10, ID: "A/B/C 0 1 1 0"}, bad, "ok"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status, msg := check(tc.rec, tc.ref)
+			if status != tc.status {
+				t.Fatalf("invalid status: got=%q (%s), want=%q", status, msg, tc.status)
+			}
+		})
+	}
+}
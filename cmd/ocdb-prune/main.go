@@ -0,0 +1,167 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-prune finds the files of a local OCDB storage that are
+// superseded by higher versions over their whole run range, and thus never
+// selected by AliCDBLocal.
+//
+// Superseded files are only listed, unless -apply is given: they are then
+// moved to a trash directory, keeping their layout.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/alice-go/aligo/ocdb"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	log.SetPrefix("ocdb-prune: ")
+	log.SetFlags(0)
+
+	var (
+		pattern = flag.String("path", "*/*/*", "only prune paths matching this pattern")
+		trash   = flag.String("trash", "", "directory where superseded files are moved")
+		apply   = flag.Bool("apply", false, "move superseded files to the trash directory (default: only list them)")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-prune [options] storage-dir\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *apply && *trash == "" {
+		log.Fatalf("-apply requires a -trash directory")
+	}
+
+	db, err := ocdb.NewLocal(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	ids, err := db.List(*pattern)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	var (
+		size  int64 // size of superseded files
+		freed int64 // size of moved files
+		moved int
+		nerrs int
+	)
+	ids = ocdb.Superseded(ids)
+	for _, id := range ids {
+		fname := db.Filename(id)
+		fi, err := os.Stat(fname)
+		if err != nil {
+			log.Printf("error: %v", err)
+			nerrs++
+			continue
+		}
+		size += fi.Size()
+		fmt.Printf("%s\t%d\n", fname, fi.Size())
+
+		if !*apply {
+			continue
+		}
+		rel, err := filepath.Rel(db.Dir(), fname)
+		if err == nil {
+			err = move(filepath.Join(*trash, rel), fname)
+		}
+		if err != nil {
+			log.Printf("error: %+v", err)
+			nerrs++
+			continue
+		}
+		moved++
+		freed += fi.Size()
+	}
+
+	switch {
+	case *apply:
+		log.Printf("%d superseded file(s) moved to %s, %d bytes freed", moved, *trash, freed)
+	default:
+		log.Printf("%d superseded file(s), %d bytes to free (use -apply to move them to the -trash directory)", len(ids), size)
+	}
+
+	if moved > 0 {
+		err = updateIndex(db.Dir())
+		if err != nil {
+			log.Printf("error: %+v", err)
+			nerrs++
+		}
+	}
+
+	if nerrs > 0 {
+		os.Exit(1)
+	}
+}
+
+// move moves the src file to dst, creating the directories of dst.
+// Files are copied if they can not be renamed, e.g. across file systems.
+func move(dst, src string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "could not create trash directory")
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return errors.Errorf("could not move %q: %q already exists", src, dst)
+	}
+
+	if os.Rename(src, dst) == nil {
+		return nil
+	}
+
+	r, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "could not open %q", src)
+	}
+	defer r.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return errors.Wrapf(err, "could not create %q", dst)
+	}
+	defer w.Close()
+
+	_, err = io.Copy(w, r)
+	if err != nil {
+		return errors.Wrapf(err, "could not copy %q to %q", src, dst)
+	}
+	err = w.Close()
+	if err != nil {
+		return errors.Wrapf(err, "could not close %q", dst)
+	}
+
+	return os.Remove(src)
+}
+
+// updateIndex updates the default index file of the storage, if it exists.
+// An index file written with another version of the index format is rebuilt.
+func updateIndex(dir string) error {
+	idx, err := ocdb.OpenIndex(dir)
+	switch {
+	case errors.Cause(err) == ocdb.ErrStaleIndex:
+		idx = ocdb.NewIndex(dir)
+	case err != nil || idx == nil:
+		return err
+	}
+	_, err = idx.Update()
+	if err != nil {
+		return err
+	}
+	return idx.Write(filepath.Join(dir, ocdb.IndexFile))
+}
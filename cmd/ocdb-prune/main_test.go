@@ -0,0 +1,94 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+func TestUpdateIndex(t *testing.T) {
+	top, err := ioutil.TempDir("", "ocdb-prune-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(top)
+
+	dir := filepath.Join(top, "OCDB")
+	err = os.Mkdir(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no index: nothing to update.
+	err = updateIndex(dir)
+	if err != nil {
+		t.Fatalf("could not update missing index: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ocdb.IndexFile)); !os.IsNotExist(err) {
+		t.Fatalf("index file created: %v", err)
+	}
+
+	db, err := ocdb.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ocdb.ParsePath("MUON/Calib/Pedestals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []ocdb.ID
+	for _, runs := range []ocdb.RunRange{ocdb.NewRunRange(0, 10), ocdb.NewRunRange(0, 20)} {
+		id, err := db.Put(ocdb.NewEntry(rbase.NewObjString("v"), ocdb.NewID(p, runs, -1, -1), nil, true))
+		if err != nil {
+			t.Fatalf("could not store entry: %+v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	idx := ocdb.NewIndex(dir)
+	_, err = idx.Update()
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+	err = idx.Write(filepath.Join(dir, ocdb.IndexFile))
+	if err != nil {
+		t.Fatalf("could not write index: %+v", err)
+	}
+
+	// the index must describe the directory it is opened from.
+	moved := filepath.Join(top, "OCDB-moved")
+	err = os.Rename(dir, moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err = ocdb.NewLocal(moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(db.Filename(ids[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = updateIndex(moved)
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+
+	err = db.LoadIndex("")
+	if err != nil {
+		t.Fatalf("could not load updated index: %+v", err)
+	}
+	es := db.Index().Entries()
+	if len(es) != 1 || es[0].File != "MUON/Calib/Pedestals/Run0_20_v2_s0.root" {
+		t.Fatalf("invalid updated index: %+v", es)
+	}
+}
@@ -5,73 +5,43 @@
 package main
 
 import (
-	"bytes"
 	"flag"
 	"fmt"
 	"io"
 	"log"
-	"mime/multipart"
 	"net/http"
-	"net/http/httputil"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 
+	"github.com/alice-go/aligo/ccdb"
 	_ "github.com/alice-go/aligo/muon/muoncalib"
 	"github.com/alice-go/aligo/ocdb"
-	"go-hep.org/x/hep/groot"
 )
 
 var (
-	ccdb   string
+	addr   string
 	srcdir string
 	dest   string
 	dry    bool
 	limit  int
+	grp    string
+	runs   string
 )
 
-func dumpRequest(r *http.Request) {
-	output, err := httputil.DumpRequest(r, false)
-	if err != nil {
-		fmt.Println("Error dumping request:", err)
-		return
-	}
-	fmt.Println(string(output))
-}
-
-func dumpResponse(r *http.Response) {
-	output, err := httputil.DumpResponse(r, false)
-	if err != nil {
-		fmt.Println("Error dumping response:", err)
-		return
-	}
-	fmt.Println(string(output))
-}
-
-func process(client *http.Client, path string, dest string, dry bool) {
-	f, err := groot.Open(path)
+func process(client *ccdb.Client, rt *runTimes, path string, dest string, dry bool) {
+	v, err := ocdb.ReadEntry(path)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer f.Close()
-	key := "AliCDBEntry"
-	o, err := f.Get(key)
+
+	from, until, err := rt.validity(path, v)
 	if err != nil {
-		log.Fatalf("Could not get key %s from file %s\n", key, path)
+		log.Fatalf("Could not compute validity of %s: %v", path, err)
 	}
-	v := o.(*ocdb.Entry)
-	// using run range as timestamp range for the moment
-	// FIXME: should read the corresponding GRP/GRP/Data object to
-	// get the run->timestamp relationship and use timestamps
-	// as validity range for the put
-	r1 := v.Id().Runs().First
-	// r2 := v.Id().Runs().Last
-	url := ccdb + "/" + dest + "/" + strconv.Itoa(int(r1))
 
 	if dry {
-		fmt.Printf("Would upload %s to %s\n", path, url)
+		fmt.Printf("Would upload %s to %s/%s [%d, %d) (%v -> %v)\n", path, addr, dest, ccdb.Millis(from), ccdb.Millis(until), from, until)
 		return
 	}
 
@@ -79,58 +49,49 @@ func process(client *http.Client, path string, dest string, dry bool) {
 	if err != nil {
 		log.Fatalf("Cannot open file %s", path)
 	}
+	defer r.Close()
 
-	var requestBody bytes.Buffer
-	mpw := multipart.NewWriter(&requestBody)
-
-	w, err := mpw.CreateFormFile("data", path)
+	o, err := client.Upload(dest, from, until, nil, path, r)
 	if err != nil {
-		log.Fatalf("Cannot create form file %s", err.Error())
+		log.Fatalf("Could not upload %s: %+v", path, err)
 	}
-
-	_, err = io.Copy(w, r)
-	if err != nil {
-		log.Fatalf("Cannot copy file to request body %s", err.Error())
-	}
-	mpw.Close()
-
-	req, err := http.NewRequest(http.MethodPost, url, &requestBody)
-	if err != nil {
-		log.Fatalf("Could not create request %s", err.Error())
-	}
-
-	req.Header.Set("Content-Type", mpw.FormDataContentType())
-	dumpRequest(req)
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatalf("Request did not go well %s", err.Error())
-	}
-	defer resp.Body.Close()
-	dumpResponse(resp)
+	fmt.Printf("Uploaded %s to %s/%s (id=%s) [%d, %d)\n", path, addr, o.Path, o.ID, o.ValidFrom, o.ValidUntil)
 }
 
 func init() {
 	flag.StringVar(&srcdir, "srcdir", "/Users/laurent/cernbox/ocdbs/2018/OCDB/MUON/Calib/OccupancyMap", "local source directory containing OCDB objects")
 	flag.StringVar(&dest, "dest", "OccupancyMap/MUON", "where to upload objects found in srcdir to")
-	flag.StringVar(&ccdb, "ccdb", "http://localhost:6464", "URL of CCDB endpoint")
+	flag.StringVar(&addr, "ccdb", "http://localhost:6464", "URL of CCDB endpoint")
 	flag.IntVar(&limit, "limit", 0, "limit the number of files that will be transfered (0 means no limit)")
+	flag.StringVar(&grp, "grp", "", "top directory of the OCDB holding the GRP/GRP/Data objects (default: the OCDB of each source file)")
+	flag.StringVar(&runs, "runs", "", "file with one 'run start end' line per run, times in seconds since epoch or RFC 3339 (takes precedence over GRP objects)")
 	flag.BoolVar(&dry, "dry", false, "only list what would happen without doing it")
 }
 
 func main() {
 	flag.Parse()
 	processed := 0
-	client := &http.Client{Timeout: 2 * time.Second}
+	client := ccdb.NewClient(addr, &http.Client{Timeout: 2 * time.Second})
+	rt, err := newRunTimes(grp, runs)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	err := filepath.Walk(srcdir, func(path string, info os.FileInfo, err error) error {
-		if strings.HasPrefix(filepath.Base(path), "Run") &&
-			filepath.Ext(path) == ".root" {
-			if limit != 0 && processed == limit {
-				return io.EOF
-			}
-			process(client, path, dest, dry)
-			processed++
+	err = filepath.Walk(srcdir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if _, _, _, err := ocdb.ParseFilename(path); err != nil {
+			return nil
+		}
+		if limit != 0 && processed == limit {
+			return io.EOF
 		}
+		process(client, rt, path, dest, dry)
+		processed++
 		return nil
 	})
 	if err != nil && err != io.EOF {
@@ -0,0 +1,154 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alice-go/aligo/ocdb"
+	"github.com/pkg/errors"
+)
+
+// runTimes maps run numbers to their start and end times.
+// Times come from a run table, if any, then from the GRP objects of an OCDB.
+type runTimes struct {
+	table map[int32][2]time.Time
+	grps  map[string]*ocdb.Local // OCDB storages, by top directory
+	grp   string                 // top directory of the OCDB holding GRP objects, if forced
+}
+
+func newRunTimes(grp, table string) (*runTimes, error) {
+	rt := &runTimes{
+		table: make(map[int32][2]time.Time),
+		grps:  make(map[string]*ocdb.Local),
+		grp:   grp,
+	}
+	if table == "" {
+		return rt, nil
+	}
+
+	f, err := os.Open(table)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open run table")
+	}
+	defer f.Close()
+
+	rt.table, err = readRunTable(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", table)
+	}
+
+	return rt, nil
+}
+
+// readRunTable reads the start and end times of runs, listed as one
+// "run start end" line per run. Lines starting with '#' are ignored.
+func readRunTable(r io.Reader) (map[int32][2]time.Time, error) {
+	table := make(map[int32][2]time.Time)
+	sc := bufio.NewScanner(r)
+	for i := 1; sc.Scan(); i++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		toks := strings.Fields(line)
+		if len(toks) != 3 {
+			return nil, errors.Errorf("line %d: invalid line %q (want: run start end)", i, line)
+		}
+		run, err := strconv.ParseInt(toks[0], 10, 32)
+		if err != nil || run < 0 {
+			return nil, errors.Errorf("line %d: invalid run number %q", i, toks[0])
+		}
+		start, err := parseTime(toks[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", i)
+		}
+		end, err := parseTime(toks[2])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", i)
+		}
+		if end.Before(start) {
+			return nil, errors.Errorf("line %d: run %d ends before it starts", i, run)
+		}
+		table[int32(run)] = [2]time.Time{start, end}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrapf(err, "could not read run table")
+	}
+	return table, nil
+}
+
+// parseTime parses a time given as seconds since the Unix epoch or in RFC 3339 format.
+func parseTime(s string) (time.Time, error) {
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(v, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, errors.Errorf("invalid time %q (want seconds since epoch or RFC 3339)", s)
+	}
+	return t.UTC(), nil
+}
+
+// validity returns the time interval covered by the run range of entry,
+// from the start of its first run to the end of its last run.
+// fname is the file entry was read from, used to locate the GRP objects
+// of the same OCDB.
+func (rt *runTimes) validity(fname string, entry *ocdb.Entry) (from, until time.Time, err error) {
+	runs := entry.Id().Runs()
+	if runs.Last == ocdb.Infinity {
+		return from, until, errors.Errorf("run range %v is open-ended: no known end time", runs)
+	}
+
+	from, _, err = rt.times(fname, runs.First)
+	if err != nil {
+		return from, until, err
+	}
+	_, until, err = rt.times(fname, runs.Last)
+	if err != nil {
+		return from, until, err
+	}
+	if !until.After(from) {
+		return from, until, errors.Errorf("run range %v maps to empty interval [%v, %v]", runs, from, until)
+	}
+	return from, until, nil
+}
+
+func (rt *runTimes) times(fname string, run int32) (start, end time.Time, err error) {
+	if v, ok := rt.table[run]; ok {
+		return v[0], v[1], nil
+	}
+
+	dir := rt.grp
+	if dir == "" {
+		// fname is <top>/<level0>/<level1>/<level2>/RunX_Y_vZ_sW.root
+		dir = fname
+		for i := 0; i < 4; i++ {
+			dir = filepath.Dir(dir)
+		}
+	}
+
+	store, ok := rt.grps[dir]
+	if !ok {
+		store, err = ocdb.NewLocal(dir)
+		if err != nil {
+			return start, end, errors.Wrapf(err, "no known time for run %d", run)
+		}
+		rt.grps[dir] = store
+	}
+
+	start, end, err = ocdb.RunTimes(store, run)
+	if err != nil {
+		return start, end, errors.Wrapf(err, "no known time for run %d", run)
+	}
+	rt.table[run] = [2]time.Time{start, end}
+	return start, end, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+func TestReadRunTable(t *testing.T) {
+	var (
+		t0 = time.Date(2018, 10, 7, 19, 10, 0, 0, time.UTC)
+		t1 = time.Date(2018, 10, 7, 21, 10, 0, 0, time.UTC)
+		t2 = time.Date(2018, 10, 8, 1, 0, 0, 0, time.UTC)
+	)
+
+	for _, tc := range []struct {
+		name string
+		txt  string
+		want map[int32][2]time.Time
+		err  string
+	}{
+		{
+			name: "empty",
+			txt:  "# run start end\n\n",
+			want: map[int32][2]time.Time{},
+		},
+		{
+			name: "epoch",
+			txt:  "297624 1538939400 1538946600\n",
+			want: map[int32][2]time.Time{297624: {t0, t1}},
+		},
+		{
+			name: "rfc3339",
+			txt:  "  297624\t2018-10-07T19:10:00Z 2018-10-07T21:10:00Z  \n297625 2018-10-07T23:10:00+02:00 1538960400\n",
+			want: map[int32][2]time.Time{297624: {t0, t1}, 297625: {t1, t2}},
+		},
+		{
+			name: "empty-run",
+			txt:  "297624 1538939400 1538939400\n",
+			want: map[int32][2]time.Time{297624: {t0, t0}},
+		},
+		{
+			name: "duplicate",
+			txt:  "297624 1538939400 1538939400\n297624 1538939400 1538946600\n",
+			want: map[int32][2]time.Time{297624: {t0, t1}},
+		},
+		{name: "fields", txt: "297624 1538939400\n", err: "line 1: invalid line"},
+		{name: "extra", txt: "# runs\n297624 1538939400 1538946600 x\n", err: "line 2: invalid line"},
+		{name: "run", txt: "run297624 1538939400 1538946600\n", err: "line 1: invalid run number"},
+		{name: "negative", txt: "-1 1538939400 1538946600\n", err: "line 1: invalid run number"},
+		{name: "overflow", txt: "2147483648 1538939400 1538946600\n", err: "line 1: invalid run number"},
+		{name: "start", txt: "297624 yesterday 1538946600\n", err: "line 1: invalid time"},
+		{name: "end", txt: "297624 1538939400 2018-10-07\n", err: "line 1: invalid time"},
+		{name: "reversed", txt: "297624 1538946600 1538939400\n", err: "line 1: run 297624 ends before it starts"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			table, err := readRunTable(strings.NewReader(tc.txt))
+			switch {
+			case tc.err != "" && err == nil:
+				t.Fatalf("expected an error, got %v", table)
+			case tc.err != "":
+				if !strings.HasPrefix(err.Error(), tc.err) {
+					t.Fatalf("invalid error:\ngot= %q\nwant=%q...", err.Error(), tc.err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("could not read run table: %+v", err)
+			}
+			if !reflect.DeepEqual(table, tc.want) {
+				t.Fatalf("invalid run table:\ngot= %v\nwant=%v", table, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunTimesValidity(t *testing.T) {
+	const run = 297624 // GRP stored in the ocdb testdata.
+	var (
+		t0 = time.Date(2018, 10, 7, 19, 10, 0, 0, time.UTC)
+		t1 = time.Date(2018, 10, 7, 21, 10, 0, 0, time.UTC)
+		t2 = time.Date(2018, 10, 8, 1, 0, 0, 0, time.UTC)
+	)
+
+	rt := &runTimes{
+		table: map[int32][2]time.Time{run + 1: {t1, t2}},
+		grps:  make(map[string]*ocdb.Local),
+		grp:   "../../ocdb/testdata",
+	}
+
+	entry := func(first, last int32) *ocdb.Entry {
+		id := ocdb.NewID(ocdb.NewPath("MUON", "Calib", "Pedestals"), ocdb.NewRunRange(first, last), 1, 0)
+		return ocdb.NewEntry(rbase.NewObjString("payload"), id, nil, true)
+	}
+
+	for _, tc := range []struct {
+		first, last int32
+		from, until time.Time
+		err         bool
+	}{
+		{first: run, last: run, from: t0, until: t1},
+		{first: run, last: run + 1, from: t0, until: t2},
+		{first: run + 1, last: run + 1, from: t1, until: t2},
+		{first: run, last: run + 2, err: true},
+		{first: run, last: ocdb.Infinity, err: true},
+	} {
+		from, until, err := rt.validity("", entry(tc.first, tc.last))
+		switch {
+		case tc.err && err == nil:
+			t.Fatalf("[%d, %d]: expected an error, got [%v, %v]", tc.first, tc.last, from, until)
+		case tc.err:
+			continue
+		case err != nil:
+			t.Fatalf("[%d, %d]: could not compute validity: %+v", tc.first, tc.last, err)
+		}
+		if !from.Equal(tc.from) || !until.Equal(tc.until) {
+			t.Fatalf("[%d, %d]: invalid validity: got=[%v, %v], want=[%v, %v]",
+				tc.first, tc.last, from, until, tc.from, tc.until,
+			)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-query resolves the OCDB objects applying to a run, a run range
+// or a list of runs, for all the paths of a local storage matching a pattern.
+//
+// Objects are selected as AliCDBLocal does: the highest version, and then
+// the highest subversion, whose run range contains the run.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	_ "github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	"github.com/pkg/errors"
+	_ "go-hep.org/x/hep/groot/ztypes"
+)
+
+// selection is the object selected for a path and a run.
+// A selection without object has a nil entry.
+type selection struct {
+	run   int32
+	path  string
+	id    ocdb.ID
+	entry *ocdb.IndexEntry // file and metadata of the selected object
+	err   error
+}
+
+func main() {
+	log.SetPrefix("ocdb-query: ")
+	log.SetFlags(0)
+
+	var (
+		runSpec = flag.String("run", "", "run number or run range (first:last)")
+		runList = flag.String("runs", "", "file listing run numbers")
+		format  = flag.String("format", "text", "output format (text, csv, matrix)")
+		index   = flag.String("index", "", "index file of the storage (default: <dir>/"+ocdb.IndexFile+", if it exists)")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-query [options] -run N|first:last|-runs file storage-dir path-pattern\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 || (*runSpec == "") == (*runList == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch *format {
+	case "text", "csv", "matrix":
+	default:
+		log.Fatalf("invalid format %q", *format)
+	}
+
+	var (
+		runs []int32
+		err  error
+	)
+	switch {
+	case *runSpec != "":
+		runs, err = parseRuns(*runSpec)
+	default:
+		runs, err = ocdb.ReadRunList(*runList)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(runs) == 0 {
+		log.Fatalf("no run to query")
+	}
+
+	db, err := ocdb.NewLocal(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	err = db.LoadIndex(*index)
+	switch {
+	case errors.Cause(err) == ocdb.ErrStaleIndex:
+		log.Printf("%v: scanning directories instead", err)
+	case err != nil:
+		log.Fatalf("%+v", err)
+	}
+
+	paths, sels, err := query(db, flag.Arg(1), runs)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if len(paths) == 0 {
+		log.Printf("no entry matching %q", flag.Arg(1))
+	}
+
+	switch *format {
+	case "text":
+		err = printText(os.Stdout, sels)
+	case "csv":
+		err = printCSV(os.Stdout, sels)
+	case "matrix":
+		err = printMatrix(os.Stdout, runs, paths, sels)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	nerrs := 0
+	for _, sel := range sels {
+		if sel.err != nil {
+			log.Printf("error: run %d: %s: %v", sel.run, sel.path, sel.err)
+			nerrs++
+		}
+	}
+	if nerrs > 0 {
+		os.Exit(1)
+	}
+}
+
+// query selects the objects of all the paths matching pattern, for each run.
+// It returns the matching paths and the selections, sorted by run and path.
+func query(db *ocdb.Local, pattern string, runs []int32) ([]string, []selection, error) {
+	ids, err := db.List(pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		paths  []string
+		byPath = make(map[string][]ocdb.ID)
+	)
+	for _, id := range ids {
+		name := id.Path().Name()
+		if _, ok := byPath[name]; !ok {
+			paths = append(paths, name)
+		}
+		byPath[name] = append(byPath[name], id)
+	}
+	sort.Strings(paths)
+
+	files := make(map[string]*ocdb.IndexEntry) // by file name
+	if idx := db.Index(); idx != nil {
+		for _, e := range idx.Entries() {
+			if e.Err != "" {
+				continue // loaded again, to report its error
+			}
+			e := e
+			files[db.Filename(e.ID())] = &e
+		}
+	}
+
+	var sels []selection
+	for _, run := range runs {
+		for _, path := range paths {
+			sel := selection{run: run, path: path}
+			ids := byPath[path]
+			id, err := ocdb.ResolveID(ids, ocdb.NewID(ids[0].Path(), ocdb.NewRunRange(run, run), -1, -1))
+			switch {
+			case errors.Cause(err) == ocdb.ErrNotFound:
+			case err != nil:
+				sel.err = err
+			default:
+				sel.id = id
+				sel.entry, sel.err = lookup(db, files, id)
+			}
+			sels = append(sels, sel)
+		}
+	}
+	return paths, sels, nil
+}
+
+// lookup returns the file and metadata of the object identified by id,
+// loading it from the storage if it is not already known.
+func lookup(db *ocdb.Local, files map[string]*ocdb.IndexEntry, id ocdb.ID) (*ocdb.IndexEntry, error) {
+	fname := db.Filename(id)
+	if e, ok := files[fname]; ok {
+		return e, nil
+	}
+
+	entry, err := db.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(db.Dir(), fname)
+	if err != nil {
+		return nil, err
+	}
+	e := &ocdb.IndexEntry{
+		File:       filepath.ToSlash(rel),
+		Path:       id.Path().Name(),
+		First:      id.Runs().First,
+		Last:       id.Runs().Last,
+		Version:    id.Version(),
+		SubVersion: id.SubVersion(),
+	}
+	if entry.Object() != nil {
+		e.Class = entry.Object().Class()
+	}
+	if meta := entry.MetaData(); meta != nil {
+		e.ObjectClassName = meta.ObjectClassName()
+		e.Responsible = meta.Responsible()
+		e.BeamPeriod = meta.BeamPeriod()
+		e.AliRootVersion = meta.AliRootVersion()
+		e.Comment = meta.Comment()
+		e.Properties = meta.Properties()
+	}
+	files[fname] = e
+	return e, nil
+}
+
+func version(e *ocdb.IndexEntry) string {
+	return fmt.Sprintf("v%d_s%d", e.Version, e.SubVersion)
+}
+
+func printText(w io.Writer, sels []selection) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for i, sel := range sels {
+		if i == 0 || sels[i-1].run != sel.run {
+			fmt.Fprintf(tw, "run %d:\n", sel.run)
+		}
+		switch e := sel.entry; {
+		case sel.err != nil:
+			fmt.Fprintf(tw, "  %s\terror: %v\n", sel.path, sel.err)
+		case e == nil:
+			fmt.Fprintf(tw, "  %s\t(no object)\n", sel.path)
+		default:
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t[%d, %d]\tclass=%q responsible=%q aliroot=%q comment=%q\n",
+				sel.path, e.File, version(e), e.First, e.Last,
+				e.Class, e.Responsible, e.AliRootVersion, e.Comment,
+			)
+		}
+	}
+	return tw.Flush()
+}
+
+func printCSV(w io.Writer, sels []selection) error {
+	header := []string{
+		"run", "path", "file", "version", "subversion", "first", "last",
+		"class", "responsible", "beamPeriod", "aliRootVersion", "comment",
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	for _, sel := range sels {
+		e := sel.entry
+		if e == nil {
+			row := make([]string, len(header))
+			row[0], row[1] = strconv.Itoa(int(sel.run)), sel.path
+			cw.Write(row)
+			continue
+		}
+		cw.Write([]string{
+			strconv.Itoa(int(sel.run)), sel.path, e.File,
+			strconv.Itoa(int(e.Version)), strconv.Itoa(int(e.SubVersion)),
+			strconv.Itoa(int(e.First)), strconv.Itoa(int(e.Last)),
+			e.Class, e.Responsible, strconv.Itoa(int(e.BeamPeriod)), e.AliRootVersion, e.Comment,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// printMatrix writes, as CSV, one row per run and one column per path,
+// holding the version of the selected object.
+// Cells are empty for runs without object, and "error" when the selection failed.
+func printMatrix(w io.Writer, runs []int32, paths []string, sels []selection) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"run"}, paths...))
+	for i, run := range runs {
+		row := []string{strconv.Itoa(int(run))}
+		for _, sel := range sels[i*len(paths) : (i+1)*len(paths)] {
+			switch {
+			case sel.err != nil:
+				row = append(row, "error")
+			case sel.entry == nil:
+				row = append(row, "")
+			default:
+				row = append(row, version(sel.entry))
+			}
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+// newTestStorage creates a local storage holding:
+//   - MUON/Calib/Gains: v1 for runs [0, Infinity], v2 for runs [20, 30],
+//   - MUON/Calib/Pedestals: v1 for runs [10, 20],
+//   - MUON/Calib/Bad: an unreadable file for runs [0, 100],
+//   - TPC/Calib/Gains: v1 for runs [0, 100].
+func newTestStorage(t *testing.T) (*ocdb.Local, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ocdb-query-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ocdb.NewLocal(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	for _, put := range []struct {
+		path        string
+		first, last int32
+	}{
+		{"MUON/Calib/Gains", 0, ocdb.Infinity},
+		{"MUON/Calib/Gains", 20, 30},
+		{"MUON/Calib/Pedestals", 10, 20},
+		{"TPC/Calib/Gains", 0, 100},
+	} {
+		p, err := ocdb.ParsePath(put.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta := ocdb.NewMetaData("tester", 0, "v5", put.path)
+		id := ocdb.NewID(p, ocdb.NewRunRange(put.first, put.last), -1, -1)
+		_, err = db.Put(ocdb.NewEntry(rbase.NewObjString(put.path), id, meta, true))
+		if err != nil {
+			t.Fatalf("could not store %v: %+v", id, err)
+		}
+	}
+
+	bad := filepath.Join(dir, "MUON", "Calib", "Bad", "Run0_100_v1_s0.root")
+	err = os.MkdirAll(filepath.Dir(bad), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(bad, []byte("not a ROOT file"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, func() { os.RemoveAll(dir) }
+}
+
+func TestQuery(t *testing.T) {
+	db, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	runs := []int32{5, 25, 200}
+	const (
+		text = `run 5:
+  MUON/Calib/Bad        error: <bad>
+  MUON/Calib/Gains      MUON/Calib/Gains/Run0_999999999_v1_s0.root  v1_s0  [0, 999999999]  class="TObjString" responsible="tester" aliroot="v5" comment="MUON/Calib/Gains"
+  MUON/Calib/Pedestals  (no object)
+run 25:
+  MUON/Calib/Bad        error: <bad>
+  MUON/Calib/Gains      MUON/Calib/Gains/Run20_30_v2_s0.root  v2_s0  [20, 30]  class="TObjString" responsible="tester" aliroot="v5" comment="MUON/Calib/Gains"
+  MUON/Calib/Pedestals  (no object)
+run 200:
+  MUON/Calib/Bad        (no object)
+  MUON/Calib/Gains      MUON/Calib/Gains/Run0_999999999_v1_s0.root  v1_s0  [0, 999999999]  class="TObjString" responsible="tester" aliroot="v5" comment="MUON/Calib/Gains"
+  MUON/Calib/Pedestals  (no object)
+`
+		csv = `run,path,file,version,subversion,first,last,class,responsible,beamPeriod,aliRootVersion,comment
+5,MUON/Calib/Bad,,,,,,,,,,
+5,MUON/Calib/Gains,MUON/Calib/Gains/Run0_999999999_v1_s0.root,1,0,0,999999999,TObjString,tester,0,v5,MUON/Calib/Gains
+5,MUON/Calib/Pedestals,,,,,,,,,,
+25,MUON/Calib/Bad,,,,,,,,,,
+25,MUON/Calib/Gains,MUON/Calib/Gains/Run20_30_v2_s0.root,2,0,20,30,TObjString,tester,0,v5,MUON/Calib/Gains
+25,MUON/Calib/Pedestals,,,,,,,,,,
+200,MUON/Calib/Bad,,,,,,,,,,
+200,MUON/Calib/Gains,MUON/Calib/Gains/Run0_999999999_v1_s0.root,1,0,0,999999999,TObjString,tester,0,v5,MUON/Calib/Gains
+200,MUON/Calib/Pedestals,,,,,,,,,,
+`
+		matrix = `run,MUON/Calib/Bad,MUON/Calib/Gains,MUON/Calib/Pedestals
+5,error,v1_s0,
+25,error,v2_s0,
+200,,v1_s0,
+`
+	)
+
+	idx := ocdb.NewIndex(db.Dir())
+	_, err := idx.Update()
+	if err != nil {
+		t.Fatalf("could not index storage: %+v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		idx  *ocdb.Index
+	}{
+		{"scan", nil},
+		{"index", idx},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db.SetIndex(tc.idx)
+			defer db.SetIndex(nil)
+
+			paths, sels, err := query(db, "MUON/*/*", runs)
+			if err != nil {
+				t.Fatalf("could not query storage: %+v", err)
+			}
+			if got, want := strings.Join(paths, ","), "MUON/Calib/Bad,MUON/Calib/Gains,MUON/Calib/Pedestals"; got != want {
+				t.Fatalf("invalid paths: got=%q, want=%q", got, want)
+			}
+			if got, want := len(sels), len(runs)*len(paths); got != want {
+				t.Fatalf("invalid number of selections: got=%d, want=%d", got, want)
+			}
+			for _, i := range []int{0, 3} {
+				if sels[i].err == nil {
+					t.Fatalf("run %d: %s: expected an error", sels[i].run, sels[i].path)
+				}
+			}
+
+			var buf bytes.Buffer
+			err = printText(&buf, sels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			for _, i := range []int{0, 3} {
+				got = strings.Replace(got, sels[i].err.Error(), "<bad>", 1)
+			}
+			if got != text {
+				t.Fatalf("invalid text output:\ngot:\n%s\nwant:\n%s", got, text)
+			}
+
+			buf.Reset()
+			err = printCSV(&buf, sels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != csv {
+				t.Fatalf("invalid CSV output:\ngot:\n%s\nwant:\n%s", got, csv)
+			}
+
+			buf.Reset()
+			err = printMatrix(&buf, runs, paths, sels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != matrix {
+				t.Fatalf("invalid matrix output:\ngot:\n%s\nwant:\n%s", got, matrix)
+			}
+		})
+	}
+
+	paths, sels, err := query(db, "ITS/*/*", runs)
+	if err != nil || len(paths) != 0 || len(sels) != 0 {
+		t.Fatalf("invalid query without matching path: paths=%q, sels=%d, err=%v", paths, len(sels), err)
+	}
+}
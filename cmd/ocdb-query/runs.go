@@ -0,0 +1,49 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxRuns is the maximum number of runs a run range may expand to.
+const maxRuns = 100000
+
+// parseRuns parses a run number or a run range of the form "first:last".
+func parseRuns(s string) ([]int32, error) {
+	toks := strings.Split(s, ":")
+	if len(toks) > 2 {
+		return nil, errors.Errorf("invalid run range %q (want: run or first:last)", s)
+	}
+	var vs [2]int32
+	for i, tok := range toks {
+		v, err := strconv.ParseInt(strings.TrimSpace(tok), 10, 32)
+		if err != nil || v < 0 {
+			return nil, errors.Errorf("invalid run number %q", tok)
+		}
+		vs[i] = int32(v)
+	}
+	if len(toks) == 1 {
+		return []int32{vs[0]}, nil
+	}
+
+	first, last := vs[0], vs[1]
+	switch {
+	case last < first:
+		return nil, errors.Errorf("invalid run range %q: last run before first run", s)
+	case int64(last)-int64(first) >= maxRuns:
+		return nil, errors.Errorf("run range %q is too large (max: %d runs)", s, maxRuns)
+	}
+	// iterate on the number of runs: run <= last always holds for last == MaxInt32.
+	n := int(last-first) + 1
+	runs := make([]int32, n)
+	for i := range runs {
+		runs[i] = first + int32(i)
+	}
+	return runs, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// span returns the n runs starting at first.
+func span(first int32, n int) []int32 {
+	runs := make([]int32, n)
+	for i := range runs {
+		runs[i] = first + int32(i)
+	}
+	return runs
+}
+
+func TestParseRuns(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		want []int32
+		err  bool
+	}{
+		{spec: "297624", want: []int32{297624}},
+		{spec: " 12 ", want: []int32{12}},
+		{spec: "0", want: []int32{0}},
+		{spec: "10:13", want: []int32{10, 11, 12, 13}},
+		{spec: "10:10", want: []int32{10}},
+		{spec: "2147483647", want: []int32{math.MaxInt32}},
+		{spec: "2147483647:2147483647", want: []int32{math.MaxInt32}},
+		{spec: "2147483645:2147483647", want: []int32{math.MaxInt32 - 2, math.MaxInt32 - 1, math.MaxInt32}},
+		{spec: "0:99999", want: span(0, maxRuns)},
+		{spec: "0:100000", err: true},
+		{spec: "0:2147483647", err: true},
+		{spec: "2147483648", err: true},
+		{spec: "13:10", err: true},
+		{spec: "-1", err: true},
+		{spec: "1:2:3", err: true},
+		{spec: "1:", err: true},
+		{spec: "run1", err: true},
+		{spec: "", err: true},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			runs, err := parseRuns(tc.spec)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected an error, got %d runs", len(runs))
+			case tc.err:
+				return
+			case err != nil:
+				t.Fatalf("could not parse runs: %+v", err)
+			}
+			if !reflect.DeepEqual(runs, tc.want) {
+				t.Fatalf("invalid runs: got=%v, want=%v", runs, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command ocdb-validate checks the consistency of OCDB files: file name,
+// location, entry ID, metadata and payload.
+//
+// It exits with a non-zero status if any error (or, with -strict, any warning)
+// is found, so it can be used to gate uploads.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/alice-go/aligo/muon/muoncalib"
+	"github.com/alice-go/aligo/ocdb"
+	_ "go-hep.org/x/hep/groot/ztypes"
+)
+
+func main() {
+	log.SetPrefix("ocdb-validate: ")
+	log.SetFlags(0)
+
+	var (
+		doJSON = flag.Bool("json", false, "print findings as JSON")
+		strict = flag.Bool("strict", false, "fail on warnings too")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ocdb-validate [options] file-or-dir [file-or-dir...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var fs []ocdb.Finding
+	for _, name := range flag.Args() {
+		fi, err := os.Stat(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !fi.IsDir() {
+			fs = append(fs, ocdb.Validate(name)...)
+			continue
+		}
+		vs, err := ocdb.ValidateTree(name)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		fs = append(fs, vs...)
+	}
+
+	if *doJSON {
+		if fs == nil {
+			fs = []ocdb.Finding{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(fs)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, f := range fs {
+			fmt.Println(f)
+		}
+	}
+
+	nerrs, nwarns := 0, 0
+	for _, f := range fs {
+		switch f.Severity {
+		case ocdb.SeverityError:
+			nerrs++
+		case ocdb.SeverityWarning:
+			nwarns++
+		}
+	}
+	if nerrs > 0 || (*strict && nwarns > 0) {
+		log.Fatalf("%d error(s), %d warning(s)", nerrs, nwarns)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/alice-go/aligo
 go 1.12
 
 require (
+	github.com/pkg/errors v0.8.1
 	github.com/stretchr/testify v1.3.0 // indirect
 	go-hep.org/x/hep v0.17.2-0.20190329113047-3e891a01729a
 )
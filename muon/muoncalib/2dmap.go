@@ -24,6 +24,17 @@ type Map2D struct {
 
 }
 
+// NewMap2D creates a new empty map.
+// If optimize is true, the pairs of identifiers are (detection element, manu).
+func NewMap2D(optimize bool) *Map2D {
+	m := &Map2D{
+		exmap: newMpExMap(),
+		opt:   optimize,
+	}
+	m.base.base = *rbase.NewObject()
+	return m
+}
+
 func (*Map2D) Class() string   { return "AliMUON2DMap" }
 func (*Map2D) RVersion() int16 { return 1 }
 
@@ -104,6 +115,20 @@ func (m *Map2D) GetObject(deid, manuid int) root.Object {
 	return nil
 }
 
+// Add stores obj under the pair of identifiers (deid, manuid),
+// replacing any object previously stored under that pair.
+func (m *Map2D) Add(deid, manuid int, obj root.Object) {
+	if m.exmap == nil {
+		m.exmap = newMpExMap()
+	}
+	sub, ok := m.exmap.get(int64(deid)).(*MpExMap)
+	if !ok {
+		sub = newMpExMap()
+		m.exmap.add(int64(deid), sub)
+	}
+	sub.add(int64(manuid), obj)
+}
+
 func (m *Map2D) GetManusForDE(deid int) []Manu {
 	var manus []Manu
 	objects := m.exmap.Objects()
@@ -0,0 +1,86 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package muoncalib
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/alice-go/aligo/ocdb"
+)
+
+func TestMap2D(t *testing.T) {
+	m := testMap()
+
+	want := []Manu{{100, 1}, {100, 2}, {1025, 4}}
+	if got := m.GetManus(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid manus: got=%v, want=%v", got, want)
+	}
+	if got := m.GetManusForDE(100); !reflect.DeepEqual(got, want[:2]) {
+		t.Fatalf("invalid manus of DE 100: got=%v, want=%v", got, want[:2])
+	}
+	if obj := m.GetObject(100, 3); obj != nil {
+		t.Fatalf("unexpected object for manu 3: %v", obj)
+	}
+
+	p := m.GetObject(100, 2).(*ParamND)
+	if p.ID0() != 100 || p.ID1() != 2 || p.Size() != 3 || p.Dimension() != 2 {
+		t.Fatalf("invalid param: id=(%d,%d) size=%d dim=%d", p.ID0(), p.ID1(), p.Size(), p.Dimension())
+	}
+	if got, want := p.Value(1, 0), 201.0; got != want {
+		t.Fatalf("invalid value: got=%v, want=%v", got, want)
+	}
+
+	// replace the object of a manu.
+	m.Add(100, 2, NewParamND(1, 1, 100, 2))
+	if got := m.GetObject(100, 2).(*ParamND); got.Size() != 1 {
+		t.Fatalf("object not replaced: size=%d", got.Size())
+	}
+	if got := m.GetManus(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid manus after replacement: got=%v, want=%v", got, want)
+	}
+}
+
+func TestMap2DFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "muoncalib-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ocdb.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := testMap()
+	id := ocdb.NewID(ocdb.NewPath("MUON", "Calib", "Pedestals"), ocdb.NewRunRange(1, 10), -1, -1)
+	id, err = db.Put(ocdb.NewEntry(m, id, ocdb.NewMetaData("tester", 0, "", ""), true))
+	if err != nil {
+		t.Fatalf("could not store map: %+v", err)
+	}
+
+	entry, err := db.Load(id)
+	if err != nil {
+		t.Fatalf("could not load map: %+v", err)
+	}
+	got, ok := entry.Object().(*Map2D)
+	if !ok {
+		t.Fatalf("invalid payload type %T", entry.Object())
+	}
+
+	if !reflect.DeepEqual(got.GetManus(), m.GetManus()) {
+		t.Fatalf("invalid manus read back: got=%v, want=%v", got.GetManus(), m.GetManus())
+	}
+	for _, manu := range m.GetManus() {
+		p := got.GetObject(manu.DeID, manu.ID).(*ParamND)
+		want := m.GetObject(manu.DeID, manu.ID).(*ParamND)
+		if p.ID0() != want.ID0() || p.ID1() != want.ID1() || !reflect.DeepEqual(p.vs, want.vs) {
+			t.Fatalf("invalid param read back for %v:\ngot= %v\nwant=%v", manu, p.vs, want.vs)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package muoncalib
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/root"
+)
+
+func init() {
+	ocdb.RegisterDisplayer("AliMUON2DMap", map2DDisplayer{})
+	ocdb.RegisterDisplayer("AliMUONCalibParamND", paramNDDisplayer{})
+}
+
+type map2DDisplayer struct{}
+
+// Summary writes the number of detection elements, manus and channels of the map,
+// and the range of the values of each dimension.
+func (map2DDisplayer) Summary(w io.Writer, obj root.Object) {
+	m := obj.(*Map2D)
+	if m.exmap == nil {
+		fmt.Fprintf(w, "%s: empty\n", m.Class())
+		return
+	}
+
+	var (
+		manus = m.GetManus()
+		des   = make(map[int]struct{})
+		nchs  = 0
+		stats []valueStats
+	)
+	for _, manu := range manus {
+		des[manu.DeID] = struct{}{}
+		p, ok := m.GetObject(manu.DeID, manu.ID).(*ParamND)
+		if !ok {
+			continue
+		}
+		nchs += p.Size()
+		for len(stats) < p.Dimension() {
+			stats = append(stats, newValueStats())
+		}
+		for i := 0; i < p.Size(); i++ {
+			for j := 0; j < p.Dimension(); j++ {
+				stats[j].add(p.Value(i, j))
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%s: %d DEs, %d manus, %d channels\n", m.Class(), len(des), len(manus), nchs)
+	for j, s := range stats {
+		fmt.Fprintf(w, "  dim %d: min=%g max=%g mean=%g\n", j, s.min, s.max, s.mean())
+	}
+}
+
+// Display writes one line per channel of the map, with its values.
+func (map2DDisplayer) Display(w io.Writer, obj root.Object) {
+	m := obj.(*Map2D)
+	if m.exmap == nil {
+		fmt.Fprintf(w, "%s: empty\n", m.Class())
+		return
+	}
+
+	fmt.Fprintf(w, "%5s %5s %3s %s\n", "DE", "MANU", "CH", "VALUES")
+	for _, manu := range m.GetManus() {
+		o := m.GetObject(manu.DeID, manu.ID)
+		p, ok := o.(*ParamND)
+		if !ok {
+			fmt.Fprintf(w, "%5d %5d   - %v\n", manu.DeID, manu.ID, o)
+			continue
+		}
+		for i := 0; i < p.Size(); i++ {
+			fmt.Fprintf(w, "%5d %5d %3d", manu.DeID, manu.ID, i)
+			for j := 0; j < p.Dimension(); j++ {
+				fmt.Fprintf(w, " %g", p.Value(i, j))
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	}
+}
+
+type paramNDDisplayer struct{}
+
+func (paramNDDisplayer) Summary(w io.Writer, obj root.Object) {
+	obj.(*ParamND).Print(w, "")
+}
+
+func (paramNDDisplayer) Display(w io.Writer, obj root.Object) {
+	obj.(*ParamND).Print(w, "FULL")
+}
+
+type valueStats struct {
+	n        int
+	min, max float64
+	sum      float64
+}
+
+func newValueStats() valueStats {
+	return valueStats{min: math.Inf(+1), max: math.Inf(-1)}
+}
+
+func (s *valueStats) add(v float64) {
+	s.n++
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+	s.sum += v
+}
+
+func (s *valueStats) mean() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.sum / float64(s.n)
+}
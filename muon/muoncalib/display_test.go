@@ -0,0 +1,42 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package muoncalib
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/root"
+)
+
+func TestDisplay(t *testing.T) {
+	m := testMap()
+	mixed := testMap()
+	mixed.Add(200, 3, rbase.NewObjString("not a param"))
+
+	for _, tc := range []struct {
+		name   string
+		obj    root.Object
+		full   bool
+		golden string
+	}{
+		{"ParamND", m.GetObject(100, 2), false, "paramnd-summary.txt"},
+		{"ParamNDFull", m.GetObject(100, 2), true, "paramnd-full.txt"},
+		{"Map2D", m, false, "map2d-summary.txt"},
+		{"Map2DFull", m, true, "map2d-full.txt"},
+		{"Mixed", mixed, false, "map2d-mixed-summary.txt"},
+		{"MixedFull", mixed, true, "map2d-mixed-full.txt"},
+		{"Empty", NewMap2D(false), false, "map2d-empty-summary.txt"},
+		{"Nil", &Map2D{}, false, "map2d-nil-summary.txt"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ocdb.DisplayObject(&buf, tc.obj, tc.full)
+			checkGolden(t, buf.Bytes(), tc.golden)
+		})
+	}
+}
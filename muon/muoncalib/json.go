@@ -0,0 +1,84 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package muoncalib
+
+import (
+	"encoding/json"
+
+	"github.com/alice-go/aligo/ocdb"
+)
+
+// MarshalJSON implements json.Marshaler.
+// Values are encoded as one array of Dimension values per channel.
+func (c *ParamND) MarshalJSON() ([]byte, error) {
+	values := make([][]float64, c.size)
+	for i := range values {
+		values[i] = make([]float64, c.dim)
+		for j := range values[i] {
+			values[i][j] = c.Value(i, j)
+		}
+	}
+	return json.Marshal(struct {
+		Class     string      `json:"class"`
+		ID0       uint32      `json:"id0"`
+		ID1       uint32      `json:"id1"`
+		Size      int32       `json:"size"`
+		Dimension int32       `json:"dimension"`
+		Values    [][]float64 `json:"values"`
+	}{c.Class(), c.ID0(), c.ID1(), c.size, c.dim, values})
+}
+
+type exMapEntry struct {
+	Key    int64           `json:"key"`
+	Object json.RawMessage `json:"object"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *MpExMap) MarshalJSON() ([]byte, error) {
+	entries := make([]exMapEntry, e.objs.Len())
+	for i := range entries {
+		obj, err := ocdb.MarshalObject(e.objs.At(i))
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = exMapEntry{Key: e.keys.At(i), Object: obj}
+	}
+	return json.Marshal(struct {
+		Class   string       `json:"class"`
+		Entries []exMapEntry `json:"entries"`
+	}{e.Class(), entries})
+}
+
+type manuEntry struct {
+	DeID   int             `json:"de"`
+	ManuID int             `json:"manu"`
+	Object json.RawMessage `json:"object"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// Objects are encoded with their (detection element, manu) pair, sorted.
+func (m *Map2D) MarshalJSON() ([]byte, error) {
+	manus := []manuEntry{}
+	if m.exmap != nil {
+		for _, manu := range m.GetManus() {
+			obj, err := ocdb.MarshalObject(m.GetObject(manu.DeID, manu.ID))
+			if err != nil {
+				return nil, err
+			}
+			manus = append(manus, manuEntry{DeID: manu.DeID, ManuID: manu.ID, Object: obj})
+		}
+	}
+	return json.Marshal(struct {
+		Class    string      `json:"class"`
+		Optimize bool        `json:"optimizeForDEManu"`
+		Manus    []manuEntry `json:"manus"`
+	}{m.Class(), m.opt, manus})
+}
+
+var (
+	_ json.Marshaler = (*ParamND)(nil)
+	_ json.Marshaler = (*MpExMap)(nil)
+	_ json.Marshaler = (*Map2D)(nil)
+)
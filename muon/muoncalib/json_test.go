@@ -0,0 +1,81 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package muoncalib
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/alice-go/aligo/ocdb"
+	"go-hep.org/x/hep/groot/root"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// testMap returns a map of pedestals of 2 manus of DE 100 and 1 manu of
+// DE 1025, with 3 channels each.
+func testMap() *Map2D {
+	m := NewMap2D(true)
+	for _, manu := range []Manu{{1025, 4}, {100, 2}, {100, 1}} {
+		p := NewParamND(2, 3, uint32(manu.DeID), uint32(manu.ID))
+		for i := 0; i < p.Size(); i++ {
+			p.SetValue(i, 0, float64(100*manu.ID+i))
+			p.SetValue(i, 1, 0.5*float64(i+1))
+		}
+		m.Add(manu.DeID, manu.ID, p)
+	}
+	return m
+}
+
+// checkGolden compares got with the content of the named golden file,
+// or updates that file.
+func checkGolden(t *testing.T, got []byte, fname string) {
+	t.Helper()
+	fname = filepath.Join("testdata", fname)
+	if *update {
+		err := ioutil.WriteFile(fname, got, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("invalid output:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	m := testMap()
+	for _, tc := range []struct {
+		name   string
+		obj    root.Object
+		golden string
+	}{
+		{"ParamND", m.GetObject(100, 2).(*ParamND), "paramnd.json"},
+		{"Map2D", m, "map2d.json"},
+		{"Empty", NewMap2D(false), "map2d-empty.json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := ocdb.MarshalObject(tc.obj)
+			if err != nil {
+				t.Fatalf("could not encode: %+v", err)
+			}
+			var buf bytes.Buffer
+			err = json.Indent(&buf, raw, "", "  ")
+			if err != nil {
+				t.Fatalf("invalid JSON %s: %+v", raw, err)
+			}
+			buf.WriteString("\n")
+			checkGolden(t, buf.Bytes(), tc.golden)
+		})
+	}
+}
@@ -81,6 +81,40 @@ func (exmap MpExMap) String() string {
 	return o.String()
 }
 
+func newMpExMap() *MpExMap {
+	return &MpExMap{
+		base: *rbase.NewObject(),
+		objs: *rcont.NewObjArray(),
+	}
+}
+
+// get returns the object stored under key, or nil.
+func (e *MpExMap) get(key int64) root.Object {
+	for i, k := range e.keys.Data {
+		if k == key {
+			return e.objs.At(i)
+		}
+	}
+	return nil
+}
+
+// add stores obj under key, replacing any object previously stored under key.
+func (e *MpExMap) add(key int64, obj root.Object) {
+	objs := make([]root.Object, e.objs.Len(), e.objs.Len()+1)
+	for i := range objs {
+		objs[i] = e.objs.At(i)
+	}
+	for i, k := range e.keys.Data {
+		if k == key {
+			objs[i] = obj
+			e.objs.SetElems(objs)
+			return
+		}
+	}
+	e.objs.SetElems(append(objs, obj))
+	e.keys.Data = append(e.keys.Data, key)
+}
+
 func (e *MpExMap) Objects() rcont.ObjArray { return e.objs }
 func (e *MpExMap) Keys() rcont.ArrayL64    { return e.keys }
 
@@ -72,6 +72,21 @@ func (o *ParamND) UnmarshalROOT(r *rbytes.RBuffer) error {
 	return r.Err()
 }
 
+// NewParamND creates a new parameter holding dim values for each of size
+// channels, all set to zero, for the pair of identifiers (id0, id1),
+// usually (detection element, manu).
+func NewParamND(dim, size int, id0, id1 uint32) *ParamND {
+	c := &ParamND{
+		dim:  int32(dim),
+		size: int32(size),
+		n:    int32(dim * size),
+		vs:   make([]float64, dim*size),
+	}
+	c.base.base = *rbase.NewObject()
+	c.base.base.ID = (id0 & 0xFFFF) | (id1&0xFFFF)<<16
+	return c
+}
+
 func (c *ParamND) index(i, j int) int {
 	return i + int(c.size)*j
 }
@@ -84,10 +99,21 @@ func (c *ParamND) ID1() uint32 {
 	return (c.base.base.ID & 0xFFFF0000) >> 16
 }
 
+// Size returns the number of channels.
+func (c *ParamND) Size() int { return int(c.size) }
+
+// Dimension returns the number of values per channel.
+func (c *ParamND) Dimension() int { return int(c.dim) }
+
 func (c *ParamND) Value(i, j int) float64 {
 	return c.vs[c.index(i, j)]
 }
 
+// SetValue sets the value of dimension j of channel i.
+func (c *ParamND) SetValue(i, j int, v float64) {
+	c.vs[c.index(i, j)] = v
+}
+
 func (c *ParamND) MeanAndSigma(dim int) (float64, float64) {
 	mean := 0.0
 	v2 := 0.0
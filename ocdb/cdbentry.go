@@ -26,13 +26,40 @@ type Entry struct {
 	owner bool         `groot:"fIsOwner"`     // ownership flag
 }
 
+// NewEntry creates a new entry holding obj, identified by id and described by meta.
+// The entry is described by a copy of meta, recording the class of obj if meta
+// does not record a class yet; meta itself is neither retained nor modified.
+func NewEntry(obj root.Object, id ID, meta *MetaData, owner bool) *Entry {
+	if meta != nil {
+		meta = meta.Clone()
+		if meta.class == "" && obj != nil {
+			meta.class = obj.Class()
+		}
+	}
+	return &Entry{
+		base:  *rbase.NewObject(),
+		obj:   obj,
+		id:    id,
+		meta:  meta,
+		owner: owner,
+	}
+}
+
 func (*Entry) Class() string   { return "AliCDBEntry" }
 func (*Entry) RVersion() int16 { return 1 }
 
 func (entry *Entry) Object() root.Object { return entry.obj }
 func (entry *Entry) Id() ID              { return entry.id }
+func (entry *Entry) MetaData() *MetaData { return entry.meta }
+func (entry *Entry) IsOwner() bool       { return entry.owner }
+
+// Display writes the entry to w, with a summary of its payload.
+func (entry *Entry) Display(w io.Writer) { entry.display(w, false) }
+
+// DisplayFull writes the entry to w, with a full dump of its payload.
+func (entry *Entry) DisplayFull(w io.Writer) { entry.display(w, true) }
 
-func (entry *Entry) Display(w io.Writer) {
+func (entry *Entry) display(w io.Writer, full bool) {
 	fmt.Fprintf(w, `=== Entry ===
 ID: %v
 Owner: %v
@@ -44,7 +71,9 @@ Owner: %v
 		entry.meta.Display(w)
 	}
 	if entry.obj != nil {
-		fmt.Fprintf(w, "Object: %T\n%v\n===\n", entry.obj, entry.obj)
+		fmt.Fprintf(w, "Object: %s\n", entry.obj.Class())
+		DisplayObject(w, entry.obj, full)
+		fmt.Fprintf(w, "===\n")
 	}
 }
 
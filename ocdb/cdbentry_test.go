@@ -0,0 +1,42 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"testing"
+
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+func TestNewEntryMetaData(t *testing.T) {
+	meta := NewMetaData("me", 0, "v5", "comment")
+	meta.SetProperty("RunUsed", "297624")
+
+	entry := NewEntry(rbase.NewObjString("payload"), NewID(NewPath("A", "B", "C"), NewRunRange(1, 2), -1, -1), meta, true)
+	if got, want := entry.MetaData().ObjectClassName(), "TObjString"; got != want {
+		t.Fatalf("invalid entry metadata class: got=%q, want=%q", got, want)
+	}
+	if got := meta.ObjectClassName(); got != "" {
+		t.Fatalf("caller metadata modified: class=%q", got)
+	}
+
+	entry.MetaData().SetProperty("RunUsed", "1")
+	if v, _ := meta.Property("RunUsed"); v != "297624" {
+		t.Fatalf("caller metadata properties modified: RunUsed=%q", v)
+	}
+
+	meta.SetObjectClassName("AliMUON2DMap")
+	entry = NewEntry(rbase.NewObjString("payload"), NewID(NewPath("A", "B", "C"), NewRunRange(1, 2), -1, -1), meta, true)
+	if entry.MetaData() == meta {
+		t.Fatalf("metadata recording a class should be copied")
+	}
+	if got, want := entry.MetaData().ObjectClassName(), "AliMUON2DMap"; got != want {
+		t.Fatalf("invalid entry metadata class: got=%q, want=%q", got, want)
+	}
+
+	if entry := NewEntry(rbase.NewObjString("payload"), NewID(NewPath("A", "B", "C"), NewRunRange(1, 2), -1, -1), nil, true); entry.MetaData() != nil {
+		t.Fatalf("nil metadata should stay nil: got=%v", entry.MetaData())
+	}
+}
@@ -26,6 +26,18 @@ type ID struct {
 	last    string       `groot:"fLastStorage"` // previous storage place (new, grid, local, dump)
 }
 
+// NewID creates a new ID for the provided path, run range, version and subversion.
+// As in AliCDBId, a negative version (or subversion) means "not specified".
+func NewID(path Path, runs RunRange, version, subversion int32) ID {
+	return ID{
+		base:    *rbase.NewObject(),
+		path:    path,
+		runs:    runs,
+		vers:    version,
+		subvers: subversion,
+	}
+}
+
 func (*ID) Class() string   { return "AliCDBId" }
 func (*ID) RVersion() int16 { return 1 }
 
@@ -74,9 +86,11 @@ func (id *ID) UnmarshalROOT(r *rbytes.RBuffer) error {
 	return r.Err()
 }
 
-func (id ID) Runs() RunRange {
-	return id.runs
-}
+func (id ID) Path() Path          { return id.path }
+func (id ID) Runs() RunRange      { return id.runs }
+func (id ID) Version() int32      { return id.vers }
+func (id ID) SubVersion() int32   { return id.subvers }
+func (id ID) LastStorage() string { return id.last }
 
 func (id ID) String() string {
 	return fmt.Sprintf("AliCDBId{Path: %v, RunRange: %v, Version: 0x%x, SubVersion: 0x%x, Last: %q}",
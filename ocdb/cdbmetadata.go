@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 
 	"go-hep.org/x/hep/groot/rbase"
 	"go-hep.org/x/hep/groot/rbytes"
@@ -29,6 +30,21 @@ type MetaData struct {
 	props   rcont.Map    `groot:"fProperties"`      // list of object specific properties
 }
 
+// NewMetaData creates new metadata with the provided responsible, beam period,
+// AliRoot version and comment.
+func NewMetaData(resp string, beam uint32, vers, comment string) *MetaData {
+	props := rcont.NewMap()
+	props.SetName("")
+	return &MetaData{
+		base:    *rbase.NewObject(),
+		resp:    resp,
+		beam:    beam,
+		vers:    vers,
+		comment: comment,
+		props:   *props,
+	}
+}
+
 func (*MetaData) Class() string   { return "AliCDBMetaData" }
 func (*MetaData) RVersion() int16 { return 1 }
 
@@ -79,12 +95,104 @@ func (meta *MetaData) UnmarshalROOT(r *rbytes.RBuffer) error {
 	return r.Err()
 }
 
+func (meta *MetaData) ObjectClassName() string { return meta.class }
+func (meta *MetaData) Responsible() string     { return meta.resp }
+func (meta *MetaData) BeamPeriod() uint32      { return meta.beam }
+func (meta *MetaData) AliRootVersion() string  { return meta.vers }
+func (meta *MetaData) Comment() string         { return meta.comment }
+
+func (meta *MetaData) SetObjectClassName(v string) { meta.class = v }
+func (meta *MetaData) SetResponsible(v string)     { meta.resp = v }
+func (meta *MetaData) SetBeamPeriod(v uint32)      { meta.beam = v }
+func (meta *MetaData) SetAliRootVersion(v string)  { meta.vers = v }
+func (meta *MetaData) SetComment(v string)         { meta.comment = v }
+
+// Clone returns a copy of the metadata.
+// Properties are copied, so that they can be modified independently.
+func (meta *MetaData) Clone() *MetaData {
+	o := *meta
+	props := rcont.NewMap()
+	props.SetName(meta.props.Name())
+	for k, v := range meta.props.Table() {
+		props.Table()[k] = v
+	}
+	o.props = *props
+	return &o
+}
+
+// Property returns the value of the string property named key,
+// e.g. "RunUsed".
+// The boolean is false if there is no such property or if its value is not a string.
+func (meta *MetaData) Property(key string) (string, bool) {
+	k := meta.propKey(key)
+	if k == nil {
+		return "", false
+	}
+	v, ok := meta.props.Table()[k].(*rbase.ObjString)
+	if !ok {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// SetProperty sets the string property named key to value,
+// replacing any previous value.
+func (meta *MetaData) SetProperty(key, value string) {
+	if meta.props.Table() == nil {
+		props := rcont.NewMap()
+		props.SetName("")
+		meta.props = *props
+	}
+	meta.RemoveProperty(key)
+	meta.props.Table()[rbase.NewObjString(key)] = rbase.NewObjString(value)
+}
+
+// RemoveProperty removes the property named key, if any.
+func (meta *MetaData) RemoveProperty(key string) {
+	if k := meta.propKey(key); k != nil {
+		delete(meta.props.Table(), k)
+	}
+}
+
+// Properties returns the string properties, by name.
+// Properties whose value is not a string are not included.
+func (meta *MetaData) Properties() map[string]string {
+	props := make(map[string]string, len(meta.props.Table()))
+	for k, v := range meta.props.Table() {
+		k, ok := k.(root.Named)
+		if !ok {
+			continue
+		}
+		v, ok := v.(*rbase.ObjString)
+		if !ok {
+			continue
+		}
+		props[k.Name()] = v.String()
+	}
+	return props
+}
+
+// propKey returns the key object of the property named key, or nil.
+func (meta *MetaData) propKey(key string) root.Object {
+	for k := range meta.props.Table() {
+		if n, ok := k.(root.Named); ok && n.Name() == key {
+			return k
+		}
+	}
+	return nil
+}
+
 func (meta *MetaData) Display(w io.Writer) {
 	fmt.Fprintf(w, "Class: %q\nResponsible: %q\nBeamPeriod: %d\nAliRoot Version: %q\nComment: %q\nProperties: %d\n",
 		meta.class, meta.resp, meta.beam, meta.vers, meta.comment, len(meta.props.Table()),
 	)
-	for k, v := range meta.props.Table() {
-		fmt.Fprintf(w, "  key: %v\n  val: %v\n", k, v)
+	keys := make([]root.Object, 0, len(meta.props.Table()))
+	for k := range meta.props.Table() {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	for _, k := range keys {
+		fmt.Fprintf(w, "  key: %v\n  val: %v\n", k, meta.props.Table()[k])
 	}
 }
 
@@ -195,6 +303,32 @@ func init() {
 	}))
 }
 
+func init() {
+	// Streamer for THashTable.
+	// THashTable is referenced by the TMap streamer, used for fProperties, and
+	// is thus needed to write AliCDBMetaData, but it is not known to groot.
+	// TMap is streamed with a custom streamer, so this StreamerInfo is only
+	// needed to describe the dependencies of TMap.
+	if _, err := rdict.Streamers.StreamerInfo("THashTable", -1); err == nil {
+		return
+	}
+	rdict.Streamers.Add(rdict.NewCxxStreamerInfo("THashTable", 0, 0, []rbytes.StreamerElement{
+		rdict.NewStreamerBase(rdict.Element{
+			Name:   *rbase.NewNamed("TCollection", "Collection abstract base class"),
+			Type:   rmeta.Base,
+			Size:   0,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 1474546588, 0, 0, 0},
+			Offset: 0,
+			EName:  "BASE",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New(), 3),
+	}))
+}
+
 var (
 	_ root.Object        = (*MetaData)(nil)
 	_ rbytes.Marshaler   = (*MetaData)(nil)
@@ -0,0 +1,86 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"reflect"
+	"testing"
+
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+func TestMetaDataProperties(t *testing.T) {
+	db, cleanup := newTestLocal(t, nil)
+	defer cleanup()
+
+	meta := NewMetaData("tester", 3, "v5-09-38", "pedestals")
+	meta.SetProperty("RunUsed", "297624")
+	meta.SetProperty("Detector", "MUON")
+	meta.SetProperty("Tmp", "x")
+	meta.SetProperty("RunUsed", "297625")
+	meta.RemoveProperty("Tmp")
+	meta.RemoveProperty("Missing")
+
+	want := map[string]string{
+		"RunUsed":  "297625",
+		"Detector": "MUON",
+	}
+	if got := meta.Properties(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid properties:\ngot= %v\nwant=%v", got, want)
+	}
+	if v, ok := meta.Property("Tmp"); ok {
+		t.Fatalf("removed property still set: %q", v)
+	}
+
+	id := NewID(NewPath("MUON", "Calib", "Pedestals"), NewRunRange(297624, 297625), -1, -1)
+	id, err := db.Put(NewEntry(rbase.NewObjString("payload"), id, meta, true))
+	if err != nil {
+		t.Fatalf("could not store entry: %+v", err)
+	}
+
+	entry, err := db.Load(id)
+	if err != nil {
+		t.Fatalf("could not load entry: %+v", err)
+	}
+	got := entry.MetaData()
+	if got == nil {
+		t.Fatalf("no metadata read back")
+	}
+	if props := got.Properties(); !reflect.DeepEqual(props, want) {
+		t.Fatalf("invalid properties read back:\ngot= %v\nwant=%v", props, want)
+	}
+	if v, ok := got.Property("RunUsed"); !ok || v != "297625" {
+		t.Fatalf("invalid RunUsed property read back: %q (ok=%v)", v, ok)
+	}
+	for _, v := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"ObjectClassName", got.ObjectClassName(), "TObjString"},
+		{"Responsible", got.Responsible(), "tester"},
+		{"BeamPeriod", got.BeamPeriod(), uint32(3)},
+		{"AliRootVersion", got.AliRootVersion(), "v5-09-38"},
+		{"Comment", got.Comment(), "pedestals"},
+	} {
+		if v.got != v.want {
+			t.Fatalf("invalid %s read back: got=%v, want=%v", v.name, v.got, v.want)
+		}
+	}
+
+	// properties of metadata read from a file can be modified and written again.
+	got.SetProperty("RunUsed", "1")
+	got.RemoveProperty("Detector")
+	id, err = db.Put(NewEntry(entry.Object(), NewID(id.Path(), id.Runs(), -1, -1), got, true))
+	if err != nil {
+		t.Fatalf("could not store entry: %+v", err)
+	}
+	entry, err = db.Load(id)
+	if err != nil {
+		t.Fatalf("could not load entry: %+v", err)
+	}
+	if props, want := entry.MetaData().Properties(), map[string]string{"RunUsed": "1"}; !reflect.DeepEqual(props, want) {
+		t.Fatalf("invalid properties read back:\ngot= %v\nwant=%v", props, want)
+	}
+}
@@ -7,6 +7,9 @@ package ocdb
 import (
 	"fmt"
 	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"go-hep.org/x/hep/groot/rbase"
 	"go-hep.org/x/hep/groot/rbytes"
@@ -27,6 +30,72 @@ type Path struct {
 	wildcard bool         `groot:"fIsWildCard"`  // wildcard flag
 }
 
+// NewPath creates a new path from its three levels.
+// Each level must be a word made of [a-zA-Z0-9_.-] characters or the "*" wildcard
+// for the path to be valid.
+func NewPath(lvl0, lvl1, lvl2 string) Path {
+	p := Path{
+		base: *rbase.NewObject(),
+		path: lvl0 + "/" + lvl1 + "/" + lvl2,
+		lvl0: lvl0,
+		lvl1: lvl1,
+		lvl2: lvl2,
+	}
+	p.valid = isLevel(lvl0) && isLevel(lvl1) && isLevel(lvl2)
+	p.wildcard = isWildcard(p.path)
+	return p
+}
+
+// ParsePath parses a path of the form "Level0/Level1/Level2", following
+// the rules of AliCDBPath:
+//   - "*" is equivalent to "*/*/*",
+//   - "Level0/*" is equivalent to "Level0/*/*",
+//   - each level must be a word or the "*" wildcard.
+//
+// ParsePath returns an error if the path is not valid.
+// The returned Path is always filled, even if invalid.
+func ParsePath(s string) (Path, error) {
+	p := Path{
+		base: *rbase.NewObject(),
+		path: s,
+	}
+
+	var toks []string
+	for _, tok := range strings.Split(strings.Trim(strings.TrimSpace(s), "/"), "/") {
+		if tok == "" {
+			continue
+		}
+		toks = append(toks, tok)
+	}
+
+	switch len(toks) {
+	case 1:
+		if toks[0] == "*" {
+			p.lvl0, p.lvl1, p.lvl2 = "*", "*", "*"
+			p.valid = true
+		}
+	case 2:
+		p.lvl0 = toks[0]
+		if isWord(p.lvl0) && toks[1] == "*" {
+			p.lvl1, p.lvl2 = "*", "*"
+			p.valid = true
+		}
+	case 3:
+		p.lvl0, p.lvl1, p.lvl2 = toks[0], toks[1], toks[2]
+		p.valid = isLevel(p.lvl0) && isLevel(p.lvl1) && isLevel(p.lvl2)
+	}
+
+	if p.valid {
+		p.path = p.lvl0 + "/" + p.lvl1 + "/" + p.lvl2
+	}
+	p.wildcard = isWildcard(p.path)
+
+	if !p.valid {
+		return p, errors.Errorf("ocdb: invalid path %q", s)
+	}
+	return p, nil
+}
+
 func (*Path) Class() string   { return "AliCDBPath" }
 func (*Path) RVersion() int16 { return 1 }
 
@@ -74,12 +143,71 @@ func (p *Path) UnmarshalROOT(r *rbytes.RBuffer) error {
 	return r.Err()
 }
 
+// Name returns the full path name (Level0/Level1/Level2).
+func (p Path) Name() string { return p.path }
+
+func (p Path) Level0() string   { return p.lvl0 }
+func (p Path) Level1() string   { return p.lvl1 }
+func (p Path) Level2() string   { return p.lvl2 }
+func (p Path) IsValid() bool    { return p.valid }
+func (p Path) IsWildcard() bool { return p.wildcard }
+
+// Comprises returns whether o is comprised by p.
+// A "*" level of p comprises any level of o.
+func (p Path) Comprises(o Path) bool {
+	return levelComprises(p.lvl0, o.lvl0) &&
+		levelComprises(p.lvl1, o.lvl1) &&
+		levelComprises(p.lvl2, o.lvl2)
+}
+
+// Match returns whether the path name is comprised by p.
+// Match returns false if name is not a valid path.
+func (p Path) Match(name string) bool {
+	o, err := ParsePath(name)
+	if err != nil {
+		return false
+	}
+	return p.Comprises(o)
+}
+
 func (p Path) String() string {
 	return fmt.Sprintf("Path{Path: %q, Level0: %q, Level1: %q, Level2: %q, Valid: %v, WildCard: %v}",
 		p.path, p.lvl0, p.lvl1, p.lvl2, p.valid, p.wildcard,
 	)
 }
 
+func levelComprises(lvl, o string) bool {
+	if lvl == "*" {
+		return true
+	}
+	return lvl == o
+}
+
+// isWord returns whether str matches ^[a-zA-Z0-9_.-]+$.
+func isWord(str string) bool {
+	if str == "" {
+		return false
+	}
+	for _, c := range str {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '_', c == '.', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isLevel(str string) bool {
+	return str == "*" || isWord(str)
+}
+
+// isWildcard mimicks TString::MaybeWildcard.
+func isWildcard(str string) bool {
+	return strings.ContainsAny(str, "[]*?")
+}
+
 func init() {
 	{
 		f := func() reflect.Value {
@@ -0,0 +1,100 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	for _, tc := range []struct {
+		str      string
+		want     string
+		wildcard bool
+		err      bool
+	}{
+		{str: "MUON/Calib/Pedestals", want: "MUON/Calib/Pedestals"},
+		{str: "/MUON/Calib/Pedestals/", want: "MUON/Calib/Pedestals"},
+		{str: " MUON//Calib/Pedestals ", want: "MUON/Calib/Pedestals"},
+		{str: "GRP/GRP/Data", want: "GRP/GRP/Data"},
+		{str: "TPC/Calib/v1.2_x-y", want: "TPC/Calib/v1.2_x-y"},
+		{str: "*", want: "*/*/*", wildcard: true},
+		{str: "MUON/*", want: "MUON/*/*", wildcard: true},
+		{str: "MUON/*/Pedestals", want: "MUON/*/Pedestals", wildcard: true},
+		{str: "*/*/*", want: "*/*/*", wildcard: true},
+		{str: "", err: true},
+		{str: "MUON", err: true},
+		{str: "MUON/Calib", err: true},
+		{str: "*/Calib", err: true},
+		{str: "MUON/Calib/Pedestals/Extra", err: true},
+		{str: "MUON/Ca lib/Pedestals", err: true},
+		{str: "MUON/Calib/Ped?", err: true},
+	} {
+		t.Run(tc.str, func(t *testing.T) {
+			p, err := ParsePath(tc.str)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected an error, got %q", p.Name())
+			case tc.err:
+				if p.IsValid() {
+					t.Fatalf("invalid path reported as valid")
+				}
+				return
+			case err != nil:
+				t.Fatalf("could not parse path: %+v", err)
+			}
+			if got := p.Name(); got != tc.want {
+				t.Fatalf("invalid path: got=%q, want=%q", got, tc.want)
+			}
+			if got := p.IsWildcard(); got != tc.wildcard {
+				t.Fatalf("invalid wildcard flag: got=%v, want=%v", got, tc.wildcard)
+			}
+			if !p.IsValid() {
+				t.Fatalf("valid path reported as invalid")
+			}
+		})
+	}
+}
+
+func TestPathComprises(t *testing.T) {
+	for _, tc := range []struct {
+		p, o string
+		want bool
+	}{
+		{"MUON/Calib/Pedestals", "MUON/Calib/Pedestals", true},
+		{"MUON/Calib/Pedestals", "MUON/Calib/Gains", false},
+		{"MUON/Calib/Gains", "MUON/Calib/Pedestals", false},
+		{"*", "MUON/Calib/Pedestals", true},
+		{"MUON/*", "MUON/Calib/Pedestals", true},
+		{"MUON/*", "TPC/Calib/Pedestals", false},
+		{"MUON/*/Pedestals", "MUON/Align/Pedestals", true},
+		{"MUON/*/Pedestals", "MUON/Align/Gains", false},
+		{"*/*/Data", "GRP/GRP/Data", true},
+		{"MUON/Calib/Pedestals", "MUON/*", false},
+		{"MUON/*", "*", false},
+		{"*", "*", true},
+	} {
+		t.Run(tc.p+"|"+tc.o, func(t *testing.T) {
+			p, err := ParsePath(tc.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := ParsePath(tc.o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Comprises(o); got != tc.want {
+				t.Fatalf("%q comprises %q: got=%v, want=%v", tc.p, tc.o, got, tc.want)
+			}
+			if got := p.Match(tc.o); got != tc.want {
+				t.Fatalf("%q matches %q: got=%v, want=%v", tc.p, tc.o, got, tc.want)
+			}
+		})
+	}
+
+	if NewPath("A", "B", "C").Match("A/B") {
+		t.Fatalf("path matched an invalid path name")
+	}
+}
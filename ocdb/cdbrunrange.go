@@ -16,6 +16,9 @@ import (
 	"go-hep.org/x/hep/groot/rtypes"
 )
 
+// Infinity is the run number used by AliRoot to denote an open-ended run range.
+const Infinity int32 = 999999999
+
 // RunRange represents a [first, last] range of run numbers.
 type RunRange struct {
 	base  rbase.Object `groot:"BASE-TObject"` // base class
@@ -23,6 +26,15 @@ type RunRange struct {
 	Last  int32        `groot:"fLastRun"`     // last valid run
 }
 
+// NewRunRange creates a new [first, last] range of run numbers.
+func NewRunRange(first, last int32) RunRange {
+	return RunRange{
+		base:  *rbase.NewObject(),
+		First: first,
+		Last:  last,
+	}
+}
+
 func (*RunRange) Class() string   { return "AliCDBRunRange" }
 func (*RunRange) RVersion() int16 { return 1 }
 
@@ -61,10 +73,107 @@ func (rr *RunRange) UnmarshalROOT(r *rbytes.RBuffer) error {
 	return r.Err()
 }
 
+// IsValid returns whether the run range is either the "any range" [-1, -1] range,
+// or a non-empty range of non-negative run numbers, as AliCDBRunRange::IsValid.
+func (rr RunRange) IsValid() bool {
+	if rr.IsAnyRange() {
+		return true
+	}
+	return rr.First >= 0 && rr.Last >= 0 && rr.First <= rr.Last
+}
+
+// isSpecified returns whether the run range is a valid range of actual runs.
+func (rr RunRange) isSpecified() bool {
+	return rr.IsValid() && !rr.IsAnyRange()
+}
+
+// IsAnyRange returns whether the run range is the "any range" [-1, -1] range.
+func (rr RunRange) IsAnyRange() bool {
+	return rr.First < 0 && rr.Last < 0
+}
+
+// IsInfinite returns whether the run range is open-ended.
+func (rr RunRange) IsInfinite() bool {
+	return rr.Last >= Infinity
+}
+
+// Contains returns whether run is within the run range.
+func (rr RunRange) Contains(run int32) bool {
+	if !rr.isSpecified() {
+		return false
+	}
+	return rr.First <= run && run <= rr.Last
+}
+
+// Overlaps returns whether both run ranges have at least one run in common.
+// Overlaps returns false if any of the run ranges is invalid or the "any range".
+func (rr RunRange) Overlaps(o RunRange) bool {
+	if !(rr.isSpecified() && o.isSpecified()) {
+		return false
+	}
+	return (rr.First < o.First && o.First <= rr.Last) ||
+		(o.First <= rr.First && rr.First <= o.Last)
+}
+
+// Comprises returns whether o is fully contained in rr.
+// Comprises returns false if any of the run ranges is invalid or the "any range".
+func (rr RunRange) Comprises(o RunRange) bool {
+	if !(rr.isSpecified() && o.isSpecified()) {
+		return false
+	}
+	return rr.First <= o.First && o.Last <= rr.Last
+}
+
+// Equal returns whether both run ranges have the same bounds.
+func (rr RunRange) Equal(o RunRange) bool {
+	return rr.First == o.First && rr.Last == o.Last
+}
+
+// Intersect returns the run range common to rr and o.
+// Intersect returns false if the run ranges do not overlap.
+func (rr RunRange) Intersect(o RunRange) (RunRange, bool) {
+	if !rr.Overlaps(o) {
+		return RunRange{}, false
+	}
+	return NewRunRange(max32(rr.First, o.First), min32(rr.Last, o.Last)), true
+}
+
+// Union returns the run range spanning both rr and o.
+// Union returns false if the run ranges neither overlap nor are adjacent,
+// as the result would then contain runs that are in none of them.
+func (rr RunRange) Union(o RunRange) (RunRange, bool) {
+	if !(rr.isSpecified() && o.isSpecified()) {
+		return RunRange{}, false
+	}
+	if !rr.Overlaps(o) && !rr.adjacent(o) {
+		return RunRange{}, false
+	}
+	return NewRunRange(min32(rr.First, o.First), max32(rr.Last, o.Last)), true
+}
+
+func (rr RunRange) adjacent(o RunRange) bool {
+	return (rr.Last < Infinity && rr.Last+1 == o.First) ||
+		(o.Last < Infinity && o.Last+1 == rr.First)
+}
+
 func (rr RunRange) String() string {
 	return fmt.Sprintf("RunRange{First: %d, Last: %d}", rr.First, rr.Last)
 }
 
+func min32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 func init() {
 	// Streamer for AliCDBRunRange.
 	rdict.Streamers.Add(rdict.NewCxxStreamerInfo("AliCDBRunRange", 1, 0x8ea5b2d7, []rbytes.StreamerElement{
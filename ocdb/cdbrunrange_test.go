@@ -0,0 +1,160 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRunRangeIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		rr    RunRange
+		valid bool
+		any   bool
+		inf   bool
+	}{
+		{rr: NewRunRange(0, 0), valid: true},
+		{rr: NewRunRange(1, 10), valid: true},
+		{rr: NewRunRange(10, Infinity), valid: true, inf: true},
+		{rr: NewRunRange(-1, -1), valid: true, any: true},
+		{rr: NewRunRange(-1, 10)},
+		{rr: NewRunRange(10, -1)},
+		{rr: NewRunRange(10, 1)},
+	} {
+		t.Run(fmt.Sprintf("%d_%d", tc.rr.First, tc.rr.Last), func(t *testing.T) {
+			if got := tc.rr.IsValid(); got != tc.valid {
+				t.Fatalf("invalid IsValid: got=%v, want=%v", got, tc.valid)
+			}
+			if got := tc.rr.IsAnyRange(); got != tc.any {
+				t.Fatalf("invalid IsAnyRange: got=%v, want=%v", got, tc.any)
+			}
+			if got := tc.rr.IsInfinite(); got != tc.inf {
+				t.Fatalf("invalid IsInfinite: got=%v, want=%v", got, tc.inf)
+			}
+		})
+	}
+}
+
+func TestRunRangeContains(t *testing.T) {
+	for _, tc := range []struct {
+		rr   RunRange
+		run  int32
+		want bool
+	}{
+		{NewRunRange(1, 10), 1, true},
+		{NewRunRange(1, 10), 5, true},
+		{NewRunRange(1, 10), 10, true},
+		{NewRunRange(1, 10), 0, false},
+		{NewRunRange(1, 10), 11, false},
+		{NewRunRange(1, Infinity), 297624, true},
+		{NewRunRange(-1, -1), 5, false},
+		{NewRunRange(10, 1), 5, false},
+	} {
+		t.Run(fmt.Sprintf("%d_%d:%d", tc.rr.First, tc.rr.Last, tc.run), func(t *testing.T) {
+			if got := tc.rr.Contains(tc.run); got != tc.want {
+				t.Fatalf("invalid Contains: got=%v, want=%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunRangeAlgebra(t *testing.T) {
+	var (
+		none     = RunRange{}
+		anyRange = NewRunRange(-1, -1)
+	)
+	for _, tc := range []struct {
+		a, b      RunRange
+		overlaps  bool
+		comprises bool // a comprises b
+		inter     RunRange
+		union     RunRange
+		unionOK   bool
+	}{
+		{
+			a: NewRunRange(1, 10), b: NewRunRange(1, 10),
+			overlaps: true, comprises: true,
+			inter: NewRunRange(1, 10), union: NewRunRange(1, 10), unionOK: true,
+		},
+		{
+			a: NewRunRange(1, 10), b: NewRunRange(5, 20),
+			overlaps: true,
+			inter:    NewRunRange(5, 10), union: NewRunRange(1, 20), unionOK: true,
+		},
+		{
+			a: NewRunRange(5, 20), b: NewRunRange(1, 10),
+			overlaps: true,
+			inter:    NewRunRange(5, 10), union: NewRunRange(1, 20), unionOK: true,
+		},
+		{
+			a: NewRunRange(1, 20), b: NewRunRange(5, 10),
+			overlaps: true, comprises: true,
+			inter: NewRunRange(5, 10), union: NewRunRange(1, 20), unionOK: true,
+		},
+		{
+			a: NewRunRange(1, 10), b: NewRunRange(10, 10),
+			overlaps: true, comprises: true,
+			inter: NewRunRange(10, 10), union: NewRunRange(1, 10), unionOK: true,
+		},
+		{
+			a: NewRunRange(1, 10), b: NewRunRange(11, 20),
+			inter: none, union: NewRunRange(1, 20), unionOK: true,
+		},
+		{
+			a: NewRunRange(11, 20), b: NewRunRange(1, 10),
+			inter: none, union: NewRunRange(1, 20), unionOK: true,
+		},
+		{
+			a: NewRunRange(1, 10), b: NewRunRange(12, 20),
+			inter: none, union: none,
+		},
+		{
+			a: NewRunRange(1, Infinity), b: NewRunRange(100, Infinity),
+			overlaps: true, comprises: true,
+			inter: NewRunRange(100, Infinity), union: NewRunRange(1, Infinity), unionOK: true,
+		},
+		{
+			a: NewRunRange(1, 10), b: anyRange,
+			inter: none, union: none,
+		},
+		{
+			a: anyRange, b: anyRange,
+			inter: none, union: none,
+		},
+		{
+			a: NewRunRange(1, 10), b: NewRunRange(8, 5),
+			inter: none, union: none,
+		},
+	} {
+		t.Run(fmt.Sprintf("%d_%d|%d_%d", tc.a.First, tc.a.Last, tc.b.First, tc.b.Last), func(t *testing.T) {
+			if got := tc.a.Overlaps(tc.b); got != tc.overlaps {
+				t.Fatalf("invalid Overlaps: got=%v, want=%v", got, tc.overlaps)
+			}
+			if got := tc.b.Overlaps(tc.a); got != tc.overlaps {
+				t.Fatalf("Overlaps is not symmetric: got=%v, want=%v", got, tc.overlaps)
+			}
+			if got := tc.a.Comprises(tc.b); got != tc.comprises {
+				t.Fatalf("invalid Comprises: got=%v, want=%v", got, tc.comprises)
+			}
+
+			inter, ok := tc.a.Intersect(tc.b)
+			if ok != tc.overlaps {
+				t.Fatalf("invalid Intersect status: got=%v, want=%v", ok, tc.overlaps)
+			}
+			if ok && !inter.Equal(tc.inter) {
+				t.Fatalf("invalid Intersect: got=%v, want=%v", inter, tc.inter)
+			}
+
+			union, ok := tc.a.Union(tc.b)
+			if ok != tc.unionOK {
+				t.Fatalf("invalid Union status: got=%v, want=%v", ok, tc.unionOK)
+			}
+			if ok && !union.Equal(tc.union) {
+				t.Fatalf("invalid Union: got=%v, want=%v", union, tc.union)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"go-hep.org/x/hep/groot/root"
+)
+
+// Displayer displays the payloads of a given ROOT class in a human readable form.
+type Displayer interface {
+	// Summary writes a compact summary of obj to w, in a few lines.
+	Summary(w io.Writer, obj root.Object)
+	// Display writes a full dump of obj to w.
+	Display(w io.Writer, obj root.Object)
+}
+
+var displayers = struct {
+	sync.RWMutex
+	db map[string]Displayer
+}{
+	db: make(map[string]Displayer),
+}
+
+// RegisterDisplayer registers the displayer for payloads of the named ROOT class,
+// replacing any previously registered one.
+func RegisterDisplayer(class string, d Displayer) {
+	displayers.Lock()
+	defer displayers.Unlock()
+	displayers.db[class] = d
+}
+
+// maxSummary is the maximum length of the summary of a payload without displayer.
+const maxSummary = 256
+
+// DisplayObject writes obj to w, using the displayer registered for its class.
+// If full is false, only a summary of obj is written.
+//
+// Objects of classes without displayer are written with their
+// Display(io.Writer) method if they have one, or with fmt.
+// Their summary is truncated.
+func DisplayObject(w io.Writer, obj root.Object, full bool) {
+	if obj == nil {
+		fmt.Fprintf(w, "<nil>\n")
+		return
+	}
+
+	displayers.RLock()
+	d, ok := displayers.db[obj.Class()]
+	displayers.RUnlock()
+
+	switch {
+	case ok && full:
+		d.Display(w, obj)
+	case ok:
+		d.Summary(w, obj)
+	default:
+		if v, ok := obj.(interface{ Display(io.Writer) }); ok {
+			v.Display(w)
+			return
+		}
+		str := fmt.Sprintf("%v", obj)
+		if !full && len(str) > maxSummary {
+			str = fmt.Sprintf("%s... (%d bytes)", str[:maxSummary], len(str))
+		}
+		fmt.Fprintf(w, "%s\n", str)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/root"
+)
+
+// displayed is a payload with a registered displayer.
+type displayed struct{ rbase.ObjString }
+
+func (*displayed) Class() string { return "ocdb.displayed" }
+
+type testDisplayer struct{}
+
+func (testDisplayer) Summary(w io.Writer, obj root.Object) {
+	fmt.Fprintf(w, "summary of %s\n", obj.(*displayed).String())
+}
+
+func (testDisplayer) Display(w io.Writer, obj root.Object) {
+	fmt.Fprintf(w, "display of %s\n", obj.(*displayed).String())
+}
+
+func init() {
+	RegisterDisplayer("ocdb.displayed", testDisplayer{})
+}
+
+func TestDisplayObject(t *testing.T) {
+	long := strings.Repeat("0123456789", 30)
+	grp := &GRPObject{
+		start:   1538939400,
+		end:     1538946600,
+		energy:  6369.5,
+		beam:    "p-p",
+		ndets:   17,
+		dets:    0x3ffff,
+		period:  "LHC18o",
+		runtype: "PHYSICS",
+		state:   "STABLE BEAMS",
+		dippol:  1,
+		l3:      []float32{30003.5},
+		dip:     []float32{5999.5},
+	}
+	grpText := `Start: 2018-10-07 19:10:00 +0000 UTC
+End: 2018-10-07 21:10:00 +0000 UTC
+Beam: "p-p" (6369.5 GeV)
+Run type: "PHYSICS"
+LHC period: "LHC18o"
+LHC state: "STABLE BEAMS"
+Detectors: 17 (mask=0x3ffff)
+L3: 30003.5 A (polarity=0)
+Dipole: 5999.5 A (polarity=1)
+`
+
+	for _, tc := range []struct {
+		name string
+		obj  root.Object
+		full bool
+		want string
+	}{
+		{"nil", nil, false, "<nil>\n"},
+		{"displayer-summary", &displayed{*rbase.NewObjString("v1")}, false, "summary of v1\n"},
+		{"displayer-full", &displayed{*rbase.NewObjString("v1")}, true, "display of v1\n"},
+		{"display-method", grp, false, grpText},
+		{"fmt", rbase.NewObjString("v1"), false, "v1\n"},
+		{"fmt-truncated", rbase.NewObjString(long), false, long[:maxSummary] + "... (300 bytes)\n"},
+		{"fmt-full", rbase.NewObjString(long), true, long + "\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			DisplayObject(&buf, tc.obj, tc.full)
+			if got := buf.String(); got != tc.want {
+				t.Fatalf("invalid output:\ngot:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEntryDisplay(t *testing.T) {
+	meta := NewMetaData("tester", 3, "v5-09-38", "pedestals")
+	meta.SetProperty("RunUsed", "297624")
+	id := NewID(NewPath("MUON", "Calib", "Pedestals"), NewRunRange(1, 10), 2, 1)
+	entry := NewEntry(&displayed{*rbase.NewObjString("v1")}, id, meta, true)
+
+	for _, tc := range []struct {
+		name    string
+		display func(io.Writer)
+		object  string
+	}{
+		{"summary", entry.Display, "summary of v1\n"},
+		{"full", entry.DisplayFull, "display of v1\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc.display(&buf)
+			want := fmt.Sprintf(`=== Entry ===
+ID: %v
+Owner: true
+MetaData:
+Class: "ocdb.displayed"
+Responsible: "tester"
+BeamPeriod: 3
+AliRoot Version: "v5-09-38"
+Comment: "pedestals"
+Properties: 1
+  key: RunUsed
+  val: 297624
+Object: ocdb.displayed
+%s===
+`, id, tc.object)
+			if got := buf.String(); got != want {
+				t.Fatalf("invalid output:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
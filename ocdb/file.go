@@ -0,0 +1,68 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"go-hep.org/x/hep/groot"
+)
+
+// EntryKey is the name of the key under which an entry is stored in an OCDB file.
+const EntryKey = "AliCDBEntry"
+
+// ReadEntry reads the entry stored in the named OCDB file.
+func ReadEntry(fname string) (*Entry, error) {
+	f, err := groot.Open(fname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: could not open file %q", fname)
+	}
+	defer f.Close()
+
+	o, err := f.Get(EntryKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: could not get key %q from file %q", EntryKey, fname)
+	}
+
+	entry, ok := o.(*Entry)
+	if !ok {
+		return nil, errors.Errorf("ocdb: key %q from file %q is a %T, not an entry", EntryKey, fname, o)
+	}
+
+	return entry, nil
+}
+
+// WriteEntry writes entry into a new OCDB file.
+// WriteEntry fails if the file already exists.
+func WriteEntry(fname string, entry *Entry) error {
+	_, err := os.Stat(fname)
+	switch {
+	case err == nil:
+		return errors.Errorf("ocdb: file %q already exists", fname)
+	case !os.IsNotExist(err):
+		return errors.Wrapf(err, "ocdb: could not access file %q", fname)
+	}
+
+	f, err := groot.Create(fname)
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: could not create file %q", fname)
+	}
+
+	err = f.Put(EntryKey, entry)
+	if err != nil {
+		f.Close()
+		os.Remove(fname)
+		return errors.Wrapf(err, "ocdb: could not write entry to file %q", fname)
+	}
+
+	err = f.Close()
+	if err != nil {
+		os.Remove(fname)
+		return errors.Wrapf(err, "ocdb: could not close file %q", fname)
+	}
+
+	return nil
+}
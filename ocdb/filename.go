@@ -0,0 +1,78 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+var reFilename = regexp.MustCompile(`^Run([0-9]+)_([0-9]+)_v([0-9]+)_s([0-9]+)\.root$`)
+
+// ParseFilename parses an OCDB file name of the form:
+//
+//	Run<first>_<last>_v<version>_s<subversion>.root
+//
+// and returns the run range, version and subversion it encodes.
+// Only the base name of fname is considered.
+func ParseFilename(fname string) (RunRange, int32, int32, error) {
+	var (
+		runs RunRange
+		base = filepath.Base(fname)
+	)
+
+	m := reFilename.FindStringSubmatch(base)
+	if m == nil {
+		return runs, 0, 0, errors.Errorf("ocdb: invalid OCDB file name %q", base)
+	}
+
+	var vs [4]int32
+	for i, str := range m[1:] {
+		v, err := strconv.ParseInt(str, 10, 32)
+		if err != nil {
+			return runs, 0, 0, errors.Wrapf(err, "ocdb: invalid OCDB file name %q", base)
+		}
+		vs[i] = int32(v)
+	}
+
+	runs = NewRunRange(vs[0], vs[1])
+	return runs, vs[2], vs[3], nil
+}
+
+// FormatFilename returns the OCDB file name for the provided run range,
+// version and subversion.
+func FormatFilename(runs RunRange, version, subversion int32) string {
+	return fmt.Sprintf("Run%d_%d_v%d_s%d.root", runs.First, runs.Last, version, subversion)
+}
+
+// ParseID builds the ID of an OCDB file from its location, of the form:
+//
+//	[...]/Level0/Level1/Level2/Run<first>_<last>_v<version>_s<subversion>.root
+func ParseID(fname string) (ID, error) {
+	var id ID
+
+	runs, vers, subvers, err := ParseFilename(fname)
+	if err != nil {
+		return id, err
+	}
+
+	dir := filepath.Dir(fname)
+	lvl2 := filepath.Base(dir)
+	dir = filepath.Dir(dir)
+	lvl1 := filepath.Base(dir)
+	dir = filepath.Dir(dir)
+	lvl0 := filepath.Base(dir)
+
+	path := NewPath(lvl0, lvl1, lvl2)
+	if !path.IsValid() || path.IsWildcard() {
+		return id, errors.Errorf("ocdb: invalid OCDB path %q for file %q", path.Name(), fname)
+	}
+
+	return NewID(path, runs, vers, subvers), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFilename(t *testing.T) {
+	for _, tc := range []struct {
+		fname   string
+		runs    RunRange
+		vers    int32
+		subvers int32
+		err     bool
+	}{
+		{fname: "Run0_999999999_v1_s0.root", runs: NewRunRange(0, Infinity), vers: 1, subvers: 0},
+		{fname: "Run297624_297624_v2_s3.root", runs: NewRunRange(297624, 297624), vers: 2, subvers: 3},
+		{fname: "ocdb/MUON/Calib/Pedestals/Run1_10_v4_s0.root", runs: NewRunRange(1, 10), vers: 4, subvers: 0},
+		{fname: "Run1_10_v1_s0.root.bak", err: true},
+		{fname: "Run1_10_v1.root", err: true},
+		{fname: "run1_10_v1_s0.root", err: true},
+		{fname: "Run-1_10_v1_s0.root", err: true},
+		{fname: "Run1_99999999999_v1_s0.root", err: true},
+		{fname: "", err: true},
+	} {
+		t.Run(tc.fname, func(t *testing.T) {
+			runs, vers, subvers, err := ParseFilename(tc.fname)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected an error")
+			case tc.err:
+				return
+			case err != nil:
+				t.Fatalf("could not parse file name: %+v", err)
+			}
+			if !runs.Equal(tc.runs) {
+				t.Fatalf("invalid run range: got=%v, want=%v", runs, tc.runs)
+			}
+			if vers != tc.vers || subvers != tc.subvers {
+				t.Fatalf("invalid version: got=v%d_s%d, want=v%d_s%d", vers, subvers, tc.vers, tc.subvers)
+			}
+
+			name := FormatFilename(runs, vers, subvers)
+			if got, want := name, filepath.Base(tc.fname); got != want {
+				t.Fatalf("invalid round-trip: got=%q, want=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestFormatFilename(t *testing.T) {
+	for _, tc := range []struct {
+		runs    RunRange
+		vers    int32
+		subvers int32
+		want    string
+	}{
+		{NewRunRange(0, Infinity), 1, 0, "Run0_999999999_v1_s0.root"},
+		{NewRunRange(297624, 297624), 12, 3, "Run297624_297624_v12_s3.root"},
+	} {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := FormatFilename(tc.runs, tc.vers, tc.subvers); got != tc.want {
+				t.Fatalf("invalid file name: got=%q, want=%q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseID(t *testing.T) {
+	for _, tc := range []struct {
+		fname string
+		want  ID
+		err   bool
+	}{
+		{
+			fname: filepath.Join("ocdb", "MUON", "Calib", "Pedestals", "Run1_10_v4_s2.root"),
+			want:  NewID(NewPath("MUON", "Calib", "Pedestals"), NewRunRange(1, 10), 4, 2),
+		},
+		{
+			fname: filepath.Join("GRP", "GRP", "Data", "Run0_999999999_v1_s0.root"),
+			want:  NewID(NewPath("GRP", "GRP", "Data"), NewRunRange(0, Infinity), 1, 0),
+		},
+		{fname: filepath.Join("ocdb", "MUON", "Calib", "Ped estals", "Run1_10_v4_s2.root"), err: true},
+		{fname: filepath.Join("ocdb", "MUON", "Calib", "Pedestals", "Run1_10.root"), err: true},
+	} {
+		t.Run(tc.fname, func(t *testing.T) {
+			id, err := ParseID(tc.fname)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected an error")
+			case tc.err:
+				return
+			case err != nil:
+				t.Fatalf("could not parse ID: %+v", err)
+			}
+			if id.Path().Name() != tc.want.Path().Name() ||
+				!id.Runs().Equal(tc.want.Runs()) ||
+				id.Version() != tc.want.Version() ||
+				id.SubVersion() != tc.want.SubVersion() {
+				t.Fatalf("invalid ID:\ngot= %v\nwant=%v", id, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Filter selects entries by their metadata.
+//
+// A filter is made of expressions of the form <field><op><value>, all of
+// which must match. Fields are:
+//   - class: the class of the object, as recorded in the metadata,
+//   - responsible, comment, aliroot: the corresponding metadata,
+//   - prop:<key>: the string property named key.
+//
+// The '=' operator matches values exactly, the '~' operator matches values
+// against a regular expression, e.g.:
+//
+//	class=AliMUON2DMap responsible~"MUON" comment~Occupancy prop:RunUsed=297624
+//
+// Values may be double-quoted.
+type Filter struct {
+	terms []term
+}
+
+type term struct {
+	field string         // field name
+	key   string         // property key, for the "prop" field
+	value string         // value, for the '=' operator
+	re    *regexp.Regexp // regular expression, for the '~' operator
+}
+
+// ParseFilter parses the provided filter expressions.
+// An empty filter matches all entries.
+func ParseFilter(exprs ...string) (Filter, error) {
+	var f Filter
+	for _, expr := range exprs {
+		i := strings.IndexAny(expr, "=~")
+		if i < 0 {
+			return f, errors.Errorf("ocdb: invalid filter expression %q (want: field=value or field~regexp)", expr)
+		}
+		t := term{field: expr[:i]}
+		switch {
+		case t.field == "class", t.field == "responsible", t.field == "comment", t.field == "aliroot":
+		case strings.HasPrefix(t.field, "prop:") && len(t.field) > len("prop:"):
+			t.field, t.key = "prop", t.field[len("prop:"):]
+		default:
+			return f, errors.Errorf("ocdb: invalid filter field %q in %q", t.field, expr)
+		}
+
+		value := expr[i+1:]
+		if strings.HasPrefix(value, `"`) {
+			v, err := strconv.Unquote(value)
+			if err != nil {
+				return f, errors.Errorf("ocdb: invalid quoted value in filter expression %q", expr)
+			}
+			value = v
+		}
+
+		switch expr[i] {
+		case '=':
+			t.value = value
+		case '~':
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return f, errors.Wrapf(err, "ocdb: invalid regular expression in filter expression %q", expr)
+			}
+			t.re = re
+		}
+		f.terms = append(f.terms, t)
+	}
+	return f, nil
+}
+
+// Match returns whether the provided metadata matches the filter.
+// Nil metadata only matches an empty filter.
+func (f Filter) Match(meta *MetaData) bool {
+	if meta == nil {
+		return len(f.terms) == 0
+	}
+	return f.match(func(t term) (string, bool) {
+		switch t.field {
+		case "class":
+			return meta.class, true
+		case "responsible":
+			return meta.resp, true
+		case "comment":
+			return meta.comment, true
+		case "aliroot":
+			return meta.vers, true
+		default:
+			return meta.Property(t.key)
+		}
+	})
+}
+
+// MatchIndex returns whether the indexed entry matches the filter.
+func (f Filter) MatchIndex(e IndexEntry) bool {
+	return f.match(func(t term) (string, bool) {
+		switch t.field {
+		case "class":
+			return e.ObjectClassName, true
+		case "responsible":
+			return e.Responsible, true
+		case "comment":
+			return e.Comment, true
+		case "aliroot":
+			return e.AliRootVersion, true
+		default:
+			v, ok := e.Properties[t.key]
+			return v, ok
+		}
+	})
+}
+
+func (f Filter) match(get func(t term) (string, bool)) bool {
+	for _, t := range f.terms {
+		v, ok := get(t)
+		if !ok {
+			return false
+		}
+		switch {
+		case t.re != nil:
+			if !t.re.MatchString(v) {
+				return false
+			}
+		case v != t.value:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	meta := NewMetaData("MUON TRK", 0, "v5-09-XX", "Computed by AliMUONOccupancySubprocessor")
+	meta.SetObjectClassName("AliMUONVStore")
+	meta.SetProperty("RunUsed", "297624")
+
+	// the indexed entry of a file holding meta, with a payload of another class.
+	e := IndexEntry{
+		Class:           "AliMUON2DMap",
+		ObjectClassName: meta.ObjectClassName(),
+		Responsible:     meta.Responsible(),
+		AliRootVersion:  meta.AliRootVersion(),
+		Comment:         meta.Comment(),
+		Properties:      meta.Properties(),
+	}
+
+	for _, tc := range []struct {
+		exprs []string
+		want  bool
+		err   bool
+	}{
+		{exprs: nil, want: true},
+		{exprs: []string{"class=AliMUONVStore"}, want: true},
+		{exprs: []string{"class=AliMUON2DMap"}, want: false},
+		{exprs: []string{"class~^AliMUON"}, want: true},
+		{exprs: []string{`responsible="MUON TRK"`}, want: true},
+		{exprs: []string{"responsible=MUON"}, want: false},
+		{exprs: []string{"responsible~MUON"}, want: true},
+		{exprs: []string{"comment~Occupancy", "aliroot~^v5"}, want: true},
+		{exprs: []string{"comment~Occupancy", "aliroot~^v6"}, want: false},
+		{exprs: []string{"prop:RunUsed=297624"}, want: true},
+		{exprs: []string{"prop:RunUsed=1"}, want: false},
+		{exprs: []string{"prop:Missing~.*"}, want: false},
+		{exprs: []string{"class"}, err: true},
+		{exprs: []string{"owner=me"}, err: true},
+		{exprs: []string{"prop:=1"}, err: true},
+		{exprs: []string{"class~("}, err: true},
+		{exprs: []string{`comment="unterminated`}, err: true},
+	} {
+		t.Run("", func(t *testing.T) {
+			f, err := ParseFilter(tc.exprs...)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("%q: expected an error", tc.exprs)
+			case tc.err:
+				return
+			case err != nil:
+				t.Fatalf("%q: could not parse filter: %+v", tc.exprs, err)
+			}
+			if got := f.Match(meta); got != tc.want {
+				t.Fatalf("%q: invalid metadata match: got=%v, want=%v", tc.exprs, got, tc.want)
+			}
+			if got := f.MatchIndex(e); got != tc.want {
+				t.Fatalf("%q: invalid index match: got=%v, want=%v", tc.exprs, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,617 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/rbytes"
+	"go-hep.org/x/hep/groot/rdict"
+	"go-hep.org/x/hep/groot/rmeta"
+	"go-hep.org/x/hep/groot/root"
+	"go-hep.org/x/hep/groot/rtypes"
+)
+
+// GRPPath is the path of the global run parameters in the OCDB.
+const GRPPath = "GRP/GRP/Data"
+
+// GRPObject holds the global run parameters (GRP) of a run,
+// as stored under GRP/GRP/Data.
+//
+// GRPObject is the equivalent of AliRoot's AliGRPObject.
+// Only the data members listed below are decoded; the other ones
+// (hall probes, pressure fits, QA thresholds, ...) are skipped.
+//
+// The layout of AliGRPObject changed across AliRoot versions, e.g. fDetectorMask
+// went from UInt_t to ULong64_t: it is decoded using the StreamerInfo stored in
+// the file it is read from.
+// GRPObject is written with the registered StreamerInfo, which describes the
+// data members up to fHallProbes, with a 64-bit fDetectorMask.
+type GRPObject struct {
+	base    rbase.Object `groot:"BASE-TObject"`       // base class
+	points  int32        `groot:"fPoints"`            // number of statistical quantities
+	dim     int32        `groot:"fDimension"`         // number of hall probe values
+	start   int64        `groot:"fTimeStart"`         // DAQ start time (seconds since the Unix epoch)
+	end     int64        `groot:"fTimeEnd"`           // DAQ end time (seconds since the Unix epoch)
+	energy  float32      `groot:"fBeamEnergy"`        // beam energy
+	beam    string       `groot:"fBeamType"`          // beam type
+	ndets   int8         `groot:"fNumberOfDetectors"` // number of detectors
+	dets    uint64       `groot:"fDetectorMask"`      // detector mask
+	period  string       `groot:"fLHCPeriod"`         // LHC period
+	runtype string       `groot:"fRunType"`           // run type
+	state   string       `groot:"fLHCState"`          // LHC state
+	l3pol   int8         `groot:"fL3Polarity"`        // L3 polarity
+	dippol  int8         `groot:"fDipolePolarity"`    // dipole polarity
+	l3      []float32    `groot:"fL3Current"`         // L3 current statistics
+	dip     []float32    `groot:"fDipoleCurrent"`     // dipole current statistics
+	cavern  []float32    `groot:"fCavernTemperature"` // cavern temperature statistics
+	hall    []float32    `groot:"fHallProbes"`        // hall probe values
+}
+
+func (*GRPObject) Class() string   { return "AliGRPObject" }
+func (*GRPObject) RVersion() int16 { return 9 }
+
+func (grp *GRPObject) TimeStart() time.Time          { return time.Unix(grp.start, 0).UTC() }
+func (grp *GRPObject) TimeEnd() time.Time            { return time.Unix(grp.end, 0).UTC() }
+func (grp *GRPObject) BeamEnergy() float32           { return grp.energy }
+func (grp *GRPObject) BeamType() string              { return grp.beam }
+func (grp *GRPObject) NumberOfDetectors() int        { return int(grp.ndets) }
+func (grp *GRPObject) DetectorMask() uint64          { return grp.dets }
+func (grp *GRPObject) LHCPeriod() string             { return grp.period }
+func (grp *GRPObject) RunType() string               { return grp.runtype }
+func (grp *GRPObject) LHCState() string              { return grp.state }
+func (grp *GRPObject) L3Polarity() int8              { return grp.l3pol }
+func (grp *GRPObject) DipolePolarity() int8          { return grp.dippol }
+func (grp *GRPObject) L3Current() float32            { return grpMean(grp.l3) }
+func (grp *GRPObject) DipoleCurrent() float32        { return grpMean(grp.dip) }
+func (grp *GRPObject) L3CurrentStats() []float32     { return grp.l3 }
+func (grp *GRPObject) DipoleCurrentStats() []float32 { return grp.dip }
+
+// grpMean returns the mean value of the statistics of a GRP quantity,
+// i.e. its first value, or 0 if there is none.
+func grpMean(stats []float32) float32 {
+	if len(stats) == 0 {
+		return 0
+	}
+	return stats[0]
+}
+
+func (grp *GRPObject) Display(w io.Writer) {
+	fmt.Fprintf(w, "Start: %v\nEnd: %v\nBeam: %q (%g GeV)\nRun type: %q\nLHC period: %q\nLHC state: %q\nDetectors: %d (mask=0x%x)\nL3: %g A (polarity=%d)\nDipole: %g A (polarity=%d)\n",
+		grp.TimeStart(), grp.TimeEnd(), grp.beam, grp.energy, grp.runtype,
+		grp.period, grp.state, grp.ndets, grp.dets,
+		grp.L3Current(), grp.l3pol, grp.DipoleCurrent(), grp.dippol,
+	)
+}
+
+// MarshalROOT implements rbytes.Marshaler
+func (grp *GRPObject) MarshalROOT(w *rbytes.WBuffer) (int, error) {
+	if w.Err() != nil {
+		return 0, w.Err()
+	}
+	for _, vs := range [][]float32{grp.l3, grp.dip, grp.cavern} {
+		if vs != nil && len(vs) != int(grp.points) {
+			return 0, errors.Errorf("ocdb: %s statistics of %d values instead of %d", grp.Class(), len(vs), grp.points)
+		}
+	}
+	if grp.hall != nil && len(grp.hall) != int(grp.dim) {
+		return 0, errors.Errorf("ocdb: %s hall probes of %d values instead of %d", grp.Class(), len(grp.hall), grp.dim)
+	}
+
+	pos := w.WriteVersion(grp.RVersion())
+
+	grp.base.MarshalROOT(w)
+	w.WriteI32(grp.points)
+	w.WriteI32(grp.dim)
+	w.WriteI64(grp.start)
+	w.WriteI64(grp.end)
+	w.WriteF32(grp.energy)
+	w.WriteString(grp.beam)
+	w.WriteI8(grp.ndets)
+	w.WriteU64(grp.dets)
+	w.WriteString(grp.period)
+	w.WriteString(grp.runtype)
+	w.WriteString(grp.state)
+	w.WriteI8(grp.l3pol)
+	w.WriteI8(grp.dippol)
+	for _, vs := range [][]float32{grp.l3, grp.dip, grp.cavern} {
+		writeFloats(w, vs)
+	}
+	w.WriteU32(0) // fCavernAtmosPressure: null pointer
+	w.WriteU32(0) // fSurfaceAtmosPressure: null pointer
+	writeFloats(w, grp.hall)
+
+	return w.SetByteCount(pos, grp.Class())
+}
+
+// writeFloats writes a Float_t array data member, whose size is given by
+// another data member.
+func writeFloats(w *rbytes.WBuffer, vs []float32) {
+	if vs == nil {
+		w.WriteI8(0)
+		return
+	}
+	w.WriteI8(1)
+	w.WriteFastArrayF32(vs)
+}
+
+// ROOTUnmarshaler is the interface implemented by an object that can
+// unmarshal itself from a ROOT buffer
+func (grp *GRPObject) UnmarshalROOT(r *rbytes.RBuffer) error {
+	if r.Err() != nil {
+		return r.Err()
+	}
+
+	v, pos, bcnt := r.ReadVersion(grp.Class())
+
+	si, err := r.StreamerInfo(grp.Class(), int(v))
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: no streamer for %s version %d", grp.Class(), v)
+	}
+
+	counts := make(map[string]int32)
+	for _, elt := range si.Elements() {
+		if r.Err() != nil {
+			return r.Err()
+		}
+		done, err := grp.readElement(r, elt, counts)
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+	if r.Err() != nil {
+		return r.Err()
+	}
+
+	if bcnt <= 0 {
+		return errors.Errorf("ocdb: %s version %d has no byte count", grp.Class(), v)
+	}
+	// skip the data members that were not decoded.
+	return r.SetPos(int64(pos) + int64(bcnt) + 4)
+}
+
+// readElement reads the data member described by elt.
+// It returns true when the remaining data members need not be read.
+func (grp *GRPObject) readElement(r *rbytes.RBuffer, elt rbytes.StreamerElement, counts map[string]int32) (bool, error) {
+	name := elt.Name()
+	typ := elt.Type()
+	n := 1
+	switch {
+	case typ > rmeta.OffsetL && typ < rmeta.OffsetP, typ == rmeta.OffsetL+rmeta.TString:
+		typ -= rmeta.OffsetL
+		n = elt.ArrayLen()
+	}
+
+	switch typ {
+	case rmeta.Base:
+		if elt.TypeName() != "BASE" || name != "TObject" {
+			return false, errors.Errorf("ocdb: unsupported base class %q in %s", name, grp.Class())
+		}
+		return false, grp.base.UnmarshalROOT(r)
+
+	case rmeta.TString:
+		vs := r.ReadFastArrayString(n)
+		if len(vs) == 0 {
+			return false, r.Err()
+		}
+		switch name {
+		case "fBeamType":
+			grp.beam = vs[0]
+		case "fLHCPeriod":
+			grp.period = vs[0]
+		case "fRunType":
+			grp.runtype = vs[0]
+		case "fLHCState":
+			grp.state = vs[0]
+		}
+
+	case rmeta.Char, rmeta.UChar, rmeta.Bool:
+		vs := r.ReadFastArrayI8(n)
+		if len(vs) == 0 {
+			return false, r.Err()
+		}
+		switch name {
+		case "fNumberOfDetectors":
+			grp.ndets = vs[0]
+		case "fL3Polarity":
+			grp.l3pol = vs[0]
+		case "fDipolePolarity":
+			grp.dippol = vs[0]
+		}
+
+	case rmeta.Short, rmeta.UShort:
+		r.ReadFastArrayI16(n)
+
+	case rmeta.Int, rmeta.UInt, rmeta.Counter:
+		vs := r.ReadFastArrayU32(n)
+		if len(vs) == 0 {
+			return false, r.Err()
+		}
+		counts[name] = int32(vs[0])
+		switch name {
+		case "fPoints":
+			grp.points = int32(vs[0])
+		case "fDimension":
+			grp.dim = int32(vs[0])
+		case "fDetectorMask":
+			grp.dets = uint64(vs[0])
+		}
+
+	case rmeta.Long, rmeta.ULong, rmeta.Long64, rmeta.ULong64:
+		vs := r.ReadFastArrayI64(n)
+		if len(vs) == 0 {
+			return false, r.Err()
+		}
+		switch name {
+		case "fTimeStart":
+			grp.start = vs[0]
+		case "fTimeEnd":
+			grp.end = vs[0]
+		case "fDetectorMask":
+			grp.dets = uint64(vs[0])
+		}
+
+	case rmeta.Float:
+		vs := r.ReadFastArrayF32(n)
+		if len(vs) == 0 {
+			return false, r.Err()
+		}
+		if name == "fBeamEnergy" {
+			grp.energy = vs[0]
+		}
+
+	case rmeta.Double:
+		r.ReadFastArrayF64(n)
+
+	case rmeta.OffsetP + rmeta.Float:
+		se, ok := elt.(*rdict.StreamerBasicPointer)
+		if !ok {
+			return false, errors.Errorf("ocdb: unsupported streamer element %q in %s", name, grp.Class())
+		}
+		var vs []float32
+		if r.ReadI8() != 0 {
+			vs = r.ReadFastArrayF32(int(counts[se.CountName()]))
+		}
+		switch name {
+		case "fL3Current":
+			grp.l3 = vs
+		case "fDipoleCurrent":
+			grp.dip = vs
+		case "fCavernTemperature":
+			grp.cavern = vs
+		case "fHallProbes":
+			grp.hall = vs
+		}
+
+	case rmeta.Object, rmeta.Any, rmeta.Objectp, rmeta.ObjectP, rmeta.Anyp, rmeta.AnyP:
+		skipObject(r)
+
+	default:
+		// unsupported data member: everything we need has been read already,
+		// the rest of the object is skipped.
+		return true, nil
+	}
+
+	return false, r.Err()
+}
+
+// skipObject skips an object, or an object pointer, streamed with a byte count.
+func skipObject(r *rbytes.RBuffer) {
+	const byteCountMask = 0x40000000
+	pos := r.Pos()
+	tag := r.ReadU32()
+	if tag&byteCountMask == 0 {
+		// null pointer or reference to an already read object.
+		return
+	}
+	r.SetPos(pos + int64(tag&^byteCountMask) + 4)
+}
+
+func init() {
+	{
+		f := func() reflect.Value {
+			var o GRPObject
+			return reflect.ValueOf(&o)
+		}
+		rtypes.Factory.Add("AliGRPObject", f)
+	}
+}
+
+func init() {
+	// Streamer for AliGRPObject, up to fHallProbes.
+	rdict.Streamers.Add(rdict.NewCxxStreamerInfo("AliGRPObject", 9, 0, []rbytes.StreamerElement{
+		rdict.NewStreamerBase(rdict.Element{
+			Name:   *rbase.NewNamed("TObject", "Basic ROOT object"),
+			Type:   rmeta.Base,
+			Size:   0,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, -1877229523, 0, 0, 0},
+			Offset: 0,
+			EName:  "BASE",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New(), 1),
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fPoints", "number of statistical quantities to be stored"),
+			Type:   rmeta.Counter,
+			Size:   4,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Int_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fDimension", "dimension of Float_t array where to store the DCS DP values"),
+			Type:   rmeta.Counter,
+			Size:   4,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Int_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fTimeStart", "DAQ_time_start entry from DAQ logbook"),
+			Type:   rmeta.Long,
+			Size:   8,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "time_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fTimeEnd", "DAQ_time_end entry from DAQ logbook"),
+			Type:   rmeta.Long,
+			Size:   8,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "time_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fBeamEnergy", "beamEnergy entry from DAQ logbook"),
+			Type:   rmeta.Float,
+			Size:   4,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Float_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerString{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fBeamType", "beamType entry from DAQ logbook"),
+			Type:   rmeta.TString,
+			Size:   24,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "TString",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fNumberOfDetectors", "numberOfDetectors entry from DAQ logbook"),
+			Type:   rmeta.Char,
+			Size:   1,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Char_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fDetectorMask", "detectorMask entry from DAQ logbook"),
+			Type:   rmeta.ULong64,
+			Size:   8,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "ULong64_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerString{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fLHCPeriod", "LHCperiod entry from DAQ logbook"),
+			Type:   rmeta.TString,
+			Size:   24,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "TString",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerString{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fRunType", "RunType entry from DAQ logbook"),
+			Type:   rmeta.TString,
+			Size:   24,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "TString",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerString{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fLHCState", "LHCState entry from DCS DB"),
+			Type:   rmeta.TString,
+			Size:   24,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "TString",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fL3Polarity", "L3Polarity entry from DCS DB"),
+			Type:   rmeta.Char,
+			Size:   1,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Char_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fDipolePolarity", "DipolePolarity entry from DCS DB"),
+			Type:   rmeta.Char,
+			Size:   1,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Char_t",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		rdict.NewStreamerBasicPointer(rdict.Element{
+			Name:   *rbase.NewNamed("fL3Current", "[fPoints]"),
+			Type:   rmeta.OffsetP + rmeta.Float,
+			Size:   4,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Float_t*",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New(), 9, "fPoints", "AliGRPObject"),
+		rdict.NewStreamerBasicPointer(rdict.Element{
+			Name:   *rbase.NewNamed("fDipoleCurrent", "[fPoints]"),
+			Type:   rmeta.OffsetP + rmeta.Float,
+			Size:   4,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Float_t*",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New(), 9, "fPoints", "AliGRPObject"),
+		rdict.NewStreamerBasicPointer(rdict.Element{
+			Name:   *rbase.NewNamed("fCavernTemperature", "[fPoints]"),
+			Type:   rmeta.OffsetP + rmeta.Float,
+			Size:   4,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Float_t*",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New(), 9, "fPoints", "AliGRPObject"),
+		&rdict.StreamerObjectPointer{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fCavernAtmosPressure", "CavernAtmosPressure entry from DCS DB"),
+			Type:   rmeta.ObjectP,
+			Size:   8,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "AliDCSSensor*",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		&rdict.StreamerObjectPointer{StreamerElement: rdict.Element{
+			Name:   *rbase.NewNamed("fSurfaceAtmosPressure", "SurfaceAtmosPressure entry from DCS DB"),
+			Type:   rmeta.ObjectP,
+			Size:   8,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "AliDCSSensor*",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New()},
+		rdict.NewStreamerBasicPointer(rdict.Element{
+			Name:   *rbase.NewNamed("fHallProbes", "[fDimension]"),
+			Type:   rmeta.OffsetP + rmeta.Float,
+			Size:   4,
+			ArrLen: 0,
+			ArrDim: 0,
+			MaxIdx: [5]int32{0, 0, 0, 0, 0},
+			Offset: 0,
+			EName:  "Float_t*",
+			XMin:   0.000000,
+			XMax:   0.000000,
+			Factor: 0.000000,
+		}.New(), 9, "fDimension", "AliGRPObject"),
+	}))
+}
+
+var (
+	_ root.Object        = (*GRPObject)(nil)
+	_ rbytes.Marshaler   = (*GRPObject)(nil)
+	_ rbytes.Unmarshaler = (*GRPObject)(nil)
+)
+
+// RunTimes returns the start and end times of run, as recorded
+// in the GRP object stored in store.
+func RunTimes(store Storage, run int32) (start, end time.Time, err error) {
+	entry, err := store.Get(GRPPath, run)
+	if err != nil {
+		return start, end, errors.Wrapf(err, "ocdb: could not retrieve GRP of run %d", run)
+	}
+	grp, ok := entry.Object().(*GRPObject)
+	if !ok {
+		return start, end, errors.Errorf("ocdb: GRP of run %d is a %T, not an AliGRPObject", run, entry.Object())
+	}
+	if grp.start <= 0 || grp.end <= 0 || grp.end < grp.start {
+		return start, end, errors.Errorf("ocdb: GRP of run %d has invalid times [%d, %d]", run, grp.start, grp.end)
+	}
+	return grp.TimeStart(), grp.TimeEnd(), nil
+}
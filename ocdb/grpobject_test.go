@@ -0,0 +1,316 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/rbytes"
+	"go-hep.org/x/hep/groot/rdict"
+	"go-hep.org/x/hep/groot/rmeta"
+)
+
+var update = flag.Bool("update", false, "update the testdata files")
+
+// grpStreamers is a StreamerInfo context holding a single AliGRPObject layout.
+type grpStreamers struct {
+	si rbytes.StreamerInfo
+}
+
+func (ctx grpStreamers) StreamerInfo(name string, version int) (rbytes.StreamerInfo, error) {
+	if name != "AliGRPObject" {
+		return nil, errors.Errorf("no streamer for %q", name)
+	}
+	return ctx.si, nil
+}
+
+// grpStreamerInfo returns the StreamerInfo of AliGRPObject, as found in OCDB
+// files, with fDetectorMask stored as an UInt_t or, if mask64 is set, as an ULong64_t.
+// Only the data members up to fHallProbes are described.
+func grpStreamerInfo(vers int32, mask64 bool) rbytes.StreamerInfo {
+	basic := func(name string, typ rmeta.Enum, size int32, ename string) rbytes.StreamerElement {
+		return &rdict.StreamerBasicType{StreamerElement: rdict.Element{
+			Name:  *rbase.NewNamed(name, ""),
+			Type:  typ,
+			Size:  size,
+			EName: ename,
+		}.New()}
+	}
+	str := func(name string) rbytes.StreamerElement {
+		return &rdict.StreamerString{StreamerElement: rdict.Element{
+			Name:  *rbase.NewNamed(name, ""),
+			Type:  rmeta.TString,
+			Size:  24,
+			EName: "TString",
+		}.New()}
+	}
+	ptr := func(name, count string) rbytes.StreamerElement {
+		return rdict.NewStreamerBasicPointer(rdict.Element{
+			Name:  *rbase.NewNamed(name, "["+count+"]"),
+			Type:  rmeta.OffsetP + rmeta.Float,
+			Size:  4,
+			EName: "Float_t*",
+		}.New(), vers, count, "AliGRPObject")
+	}
+	obj := func(name, ename string) rbytes.StreamerElement {
+		return &rdict.StreamerObjectPointer{StreamerElement: rdict.Element{
+			Name:  *rbase.NewNamed(name, ""),
+			Type:  rmeta.ObjectP,
+			Size:  8,
+			EName: ename,
+		}.New()}
+	}
+
+	mask := basic("fDetectorMask", rmeta.UInt, 4, "UInt_t")
+	if mask64 {
+		mask = basic("fDetectorMask", rmeta.ULong64, 8, "ULong64_t")
+	}
+
+	return rdict.NewCxxStreamerInfo("AliGRPObject", vers, 0, []rbytes.StreamerElement{
+		rdict.NewStreamerBase(rdict.Element{
+			Name:  *rbase.NewNamed("TObject", "Basic ROOT object"),
+			Type:  rmeta.Base,
+			EName: "BASE",
+		}.New(), 1),
+		basic("fPoints", rmeta.Counter, 4, "Int_t"),
+		basic("fDimension", rmeta.Counter, 4, "Int_t"),
+		basic("fTimeStart", rmeta.Long, 8, "time_t"),
+		basic("fTimeEnd", rmeta.Long, 8, "time_t"),
+		basic("fBeamEnergy", rmeta.Float, 4, "Float_t"),
+		str("fBeamType"),
+		basic("fNumberOfDetectors", rmeta.Char, 1, "Char_t"),
+		mask,
+		str("fLHCPeriod"),
+		str("fRunType"),
+		str("fLHCState"),
+		basic("fL3Polarity", rmeta.Char, 1, "Char_t"),
+		basic("fDipolePolarity", rmeta.Char, 1, "Char_t"),
+		ptr("fL3Current", "fPoints"),
+		ptr("fDipoleCurrent", "fPoints"),
+		ptr("fCavernTemperature", "fPoints"),
+		obj("fCavernAtmosPressure", "AliDCSSensor*"),
+		obj("fSurfaceAtmosPressure", "AliDCSSensor*"),
+		ptr("fHallProbes", "fDimension"),
+	})
+}
+
+// writeGRP writes an AliGRPObject with the layout of grpStreamerInfo.
+func writeGRP(vers int16, mask64 bool, want *GRPObject) []byte {
+	w := rbytes.NewWBuffer(nil, nil, 0, nil)
+	pos := w.WriteVersion(vers)
+	obj := rbase.NewObject()
+	obj.MarshalROOT(w)
+	w.WriteI32(int32(len(want.l3)))
+	w.WriteI32(3) // fDimension
+	w.WriteI64(want.start)
+	w.WriteI64(want.end)
+	w.WriteF32(want.energy)
+	w.WriteString(want.beam)
+	w.WriteI8(want.ndets)
+	if mask64 {
+		w.WriteU64(want.dets)
+	} else {
+		w.WriteU32(uint32(want.dets))
+	}
+	w.WriteString(want.period)
+	w.WriteString(want.runtype)
+	w.WriteString(want.state)
+	w.WriteI8(want.l3pol)
+	w.WriteI8(want.dippol)
+	for _, vs := range [][]float32{want.l3, want.dip, {20, 0.5, 19, 21, 0}} {
+		w.WriteI8(1)
+		w.WriteFastArrayF32(vs)
+	}
+	w.WriteU32(0) // fCavernAtmosPressure: null pointer
+	w.WriteU32(0) // fSurfaceAtmosPressure: null pointer
+	w.WriteI8(1)
+	w.WriteFastArrayF32([]float32{1, 2, 3})
+	w.SetByteCount(pos, "AliGRPObject")
+	return w.Bytes()
+}
+
+func TestGRPObjectUnmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		vers   int16
+		mask64 bool
+		dets   uint64
+	}{
+		{name: "mask32", vers: 6, dets: 0x3ffff},
+		{name: "mask64", vers: 9, mask64: true, dets: 0x10003ffff},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			want := &GRPObject{
+				points:  5,
+				start:   1538939400,
+				end:     1538946600,
+				energy:  6369.5,
+				beam:    "p-p",
+				ndets:   17,
+				dets:    tc.dets,
+				period:  "LHC18o",
+				runtype: "PHYSICS",
+				state:   "STABLE BEAMS",
+				l3pol:   0,
+				dippol:  1,
+				l3:      []float32{30003.5, 1.2, 30001, 30005, 30003},
+				dip:     []float32{5999.5, 0.8, 5998, 6001, 5999},
+			}
+
+			ctx := grpStreamers{grpStreamerInfo(int32(tc.vers), tc.mask64)}
+
+			raw := writeGRP(tc.vers, tc.mask64, want)
+			r := rbytes.NewRBuffer(raw, nil, 0, ctx)
+
+			var grp GRPObject
+			err := grp.UnmarshalROOT(r)
+			if err != nil {
+				t.Fatalf("could not decode GRP: %+v", err)
+			}
+			if got, want := r.Pos(), int64(len(raw)); got != want {
+				t.Fatalf("invalid buffer position: got=%d, want=%d", got, want)
+			}
+
+			for _, v := range []struct {
+				name      string
+				got, want interface{}
+			}{
+				{"TimeStart", grp.TimeStart(), time.Date(2018, 10, 7, 19, 10, 0, 0, time.UTC)},
+				{"TimeEnd", grp.TimeEnd(), time.Date(2018, 10, 7, 21, 10, 0, 0, time.UTC)},
+				{"BeamEnergy", grp.BeamEnergy(), want.energy},
+				{"BeamType", grp.BeamType(), want.beam},
+				{"NumberOfDetectors", grp.NumberOfDetectors(), int(want.ndets)},
+				{"DetectorMask", grp.DetectorMask(), tc.dets},
+				{"LHCPeriod", grp.LHCPeriod(), want.period},
+				{"RunType", grp.RunType(), want.runtype},
+				{"LHCState", grp.LHCState(), want.state},
+				{"L3Polarity", grp.L3Polarity(), want.l3pol},
+				{"DipolePolarity", grp.DipolePolarity(), want.dippol},
+				{"L3Current", grp.L3Current(), want.l3[0]},
+				{"DipoleCurrent", grp.DipoleCurrent(), want.dip[0]},
+				{"L3CurrentStats", grp.L3CurrentStats(), want.l3},
+				{"DipoleCurrentStats", grp.DipoleCurrentStats(), want.dip},
+			} {
+				if !reflect.DeepEqual(v.got, v.want) {
+					t.Errorf("invalid %s: got=%v, want=%v", v.name, v.got, v.want)
+				}
+			}
+		})
+	}
+}
+
+// testGRP returns the GRP object stored in testdata for run 297624.
+func testGRP() *GRPObject {
+	return &GRPObject{
+		base:    *rbase.NewObject(),
+		points:  5,
+		dim:     3,
+		start:   1538939400,
+		end:     1538946600,
+		energy:  6369.5,
+		beam:    "p-p",
+		ndets:   17,
+		dets:    0x10003ffff,
+		period:  "LHC18o",
+		runtype: "PHYSICS",
+		state:   "STABLE BEAMS",
+		l3pol:   0,
+		dippol:  1,
+		l3:      []float32{30003.5, 1.2, 30001, 30005, 30003},
+		dip:     []float32{5999.5, 0.8, 5998, 6001, 5999},
+		cavern:  []float32{20, 0.5, 19, 21, 20},
+		hall:    []float32{1, 2, 3},
+	}
+}
+
+func TestGRPObjectFile(t *testing.T) {
+	const run = 297624
+	want := testGRP()
+
+	dir, err := ioutil.TempDir("", "ocdb-grp-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := NewID(NewPath("GRP", "GRP", "Data"), NewRunRange(run, run), -1, -1)
+	id, err = tmp.Put(NewEntry(want, id, NewMetaData("tester", 0, "v5-09-38", "GRP"), true))
+	if err != nil {
+		t.Fatalf("could not write GRP: %+v", err)
+	}
+
+	if *update {
+		raw, err := ioutil.ReadFile(tmp.Filename(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db, err := NewLocal("testdata")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fname := db.Filename(id)
+		err = os.MkdirAll(filepath.Dir(fname), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(fname, raw, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		dir  string
+	}{
+		{"written", dir},
+		{"testdata", "testdata"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := NewLocal(tc.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry, err := db.Get(GRPPath, run)
+			if err != nil {
+				t.Fatalf("could not read GRP: %+v", err)
+			}
+			grp, ok := entry.Object().(*GRPObject)
+			if !ok {
+				t.Fatalf("invalid GRP type %T", entry.Object())
+			}
+			if !reflect.DeepEqual(grp, want) {
+				t.Fatalf("invalid GRP:\ngot= %+v\nwant=%+v", grp, want)
+			}
+
+			start, end, err := RunTimes(db, run)
+			if err != nil {
+				t.Fatalf("could not get run times: %+v", err)
+			}
+			if got, want := start, time.Date(2018, 10, 7, 19, 10, 0, 0, time.UTC); !got.Equal(want) {
+				t.Fatalf("invalid start: got=%v, want=%v", got, want)
+			}
+			if got, want := end, time.Date(2018, 10, 7, 21, 10, 0, 0, time.UTC); !got.Equal(want) {
+				t.Fatalf("invalid end: got=%v, want=%v", got, want)
+			}
+
+			if _, _, err := RunTimes(db, run+1); err == nil {
+				t.Fatalf("expected an error for a run without GRP")
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go-hep.org/x/hep/groot/rbytes"
+	"go-hep.org/x/hep/groot/rcont"
+	"go-hep.org/x/hep/groot/root"
+)
+
+// PayloadHash returns the hex-encoded SHA-256 hash of the ROOT serialization
+// of obj, including its class name.
+// As it only depends on the payload, two entries with identical payloads have
+// the same hash, whatever their ID and metadata.
+//
+// obj must be the payload of an entry, not the entry itself.
+// TMap payloads, whose serialization follows the random order of their hash
+// table, are instead hashed from the sorted hashes of their keys and values,
+// so that equal maps have the same hash.
+func PayloadHash(obj root.Object) (string, error) {
+	if obj == nil {
+		return "", errors.Errorf("ocdb: no payload to hash")
+	}
+	if _, ok := obj.(*Entry); ok {
+		return "", errors.Errorf("ocdb: could not hash an entry, only its payload")
+	}
+
+	sum, err := payloadHash(obj)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// payloadHash returns the SHA-256 hash of obj, or nil if obj is nil.
+// Maps are hashed from the sorted hashes of their keys and values.
+func payloadHash(obj root.Object) ([]byte, error) {
+	if obj == nil {
+		return nil, nil
+	}
+
+	m, ok := obj.(*rcont.Map)
+	if !ok {
+		raw, err := serialize(obj)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(raw)
+		return sum[:], nil
+	}
+
+	type pair struct{ k, v []byte }
+	pairs := make([]pair, 0, len(m.Table()))
+	for k, v := range m.Table() {
+		kh, err := payloadHash(k)
+		if err != nil {
+			return nil, err
+		}
+		vh, err := payloadHash(v)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair{kh, vh})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if c := bytes.Compare(pairs[i].k, pairs[j].k); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(pairs[i].v, pairs[j].v) < 0
+	})
+
+	h := sha256.New()
+	writeHashed(h, []byte(m.Class()))
+	writeHashed(h, []byte(m.Name()))
+	for _, p := range pairs {
+		writeHashed(h, p.k)
+		writeHashed(h, p.v)
+	}
+	return h.Sum(nil), nil
+}
+
+// serialize returns the ROOT serialization of obj, including its class name.
+func serialize(obj root.Object) ([]byte, error) {
+	if _, ok := obj.(rbytes.Marshaler); !ok {
+		return nil, errors.Errorf("ocdb: payload of type %q can not be serialized", obj.Class())
+	}
+
+	w := rbytes.NewWBuffer(nil, nil, 0, nil)
+	err := w.WriteObjectAny(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: could not serialize payload of type %q", obj.Class())
+	}
+	return w.Bytes(), nil
+}
+
+// writeHashed writes to h the length of raw, followed by raw.
+func writeHashed(h hash.Hash, raw []byte) {
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(raw)))
+	h.Write(n[:])
+	h.Write(raw)
+}
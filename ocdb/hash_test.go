@@ -0,0 +1,172 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-hep.org/x/hep/groot"
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/rcont"
+	"go-hep.org/x/hep/groot/riofs"
+	"go-hep.org/x/hep/groot/root"
+)
+
+// newTestMap returns a map of n TObjString pairs, inserted in the provided order.
+func newTestMap(n int, reverse bool) *rcont.Map {
+	m := rcont.NewMap()
+	for i := 0; i < n; i++ {
+		j := i
+		if reverse {
+			j = n - 1 - i
+		}
+		m.Table()[rbase.NewObjString(fmt.Sprintf("key-%d", j))] = rbase.NewObjString(fmt.Sprintf("val-%d", j))
+	}
+	return m
+}
+
+func TestPayloadHash(t *testing.T) {
+	hash := func(obj root.Object) string {
+		t.Helper()
+		h, err := PayloadHash(obj)
+		if err != nil {
+			t.Fatalf("could not hash %T: %+v", obj, err)
+		}
+		return h
+	}
+
+	// the hash of a payload is its ROOT serialization: it must not change.
+	if got, want := hash(rbase.NewObjString("v1")), "48a7763c293102884296a907c63a1802ed28e1b4767dddc91ccd310cb024b0c4"; got != want {
+		t.Fatalf("invalid hash: got=%s, want=%s", got, want)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		a, b  root.Object
+		equal bool
+	}{
+		{"same-string", rbase.NewObjString("v1"), rbase.NewObjString("v1"), true},
+		{"other-string", rbase.NewObjString("v1"), rbase.NewObjString("v2"), false},
+		{"same-map", newTestMap(50, false), newTestMap(50, true), true},
+		{"other-map", newTestMap(50, false), newTestMap(49, false), false},
+		{"empty-maps", rcont.NewMap(), rcont.NewMap(), true},
+		{"map-string", rcont.NewMap(), rbase.NewObjString(""), false},
+		{"nested-maps", func() root.Object {
+			m := rcont.NewMap()
+			m.Table()[rbase.NewObjString("a")] = newTestMap(20, false)
+			m.Table()[rbase.NewObjString("b")] = nil
+			return m
+		}(), func() root.Object {
+			m := rcont.NewMap()
+			m.Table()[rbase.NewObjString("b")] = nil
+			m.Table()[rbase.NewObjString("a")] = newTestMap(20, true)
+			return m
+		}(), true},
+		{"swapped-values", func() root.Object {
+			m := rcont.NewMap()
+			m.Table()[rbase.NewObjString("a")] = rbase.NewObjString("1")
+			m.Table()[rbase.NewObjString("b")] = rbase.NewObjString("2")
+			return m
+		}(), func() root.Object {
+			m := rcont.NewMap()
+			m.Table()[rbase.NewObjString("a")] = rbase.NewObjString("2")
+			m.Table()[rbase.NewObjString("b")] = rbase.NewObjString("1")
+			return m
+		}(), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ha := hash(tc.a)
+			if got := hash(tc.a); got != ha {
+				t.Fatalf("unstable hash: got=%s, then %s", ha, got)
+			}
+			if got := hash(tc.b) == ha; got != tc.equal {
+				t.Fatalf("invalid hash comparison: got equal=%v, want equal=%v", got, tc.equal)
+			}
+		})
+	}
+
+	// the hash of a map does not depend on the iteration order of its table.
+	m := newTestMap(100, false)
+	h := hash(m)
+	for i := 0; i < 20; i++ {
+		if got := hash(m); got != h {
+			t.Fatalf("unstable map hash: got=%s, want=%s", got, h)
+		}
+	}
+
+	for _, obj := range []root.Object{
+		nil,
+		NewEntry(rbase.NewObjString("v1"), NewID(NewPath("MUON", "Calib", "Gains"), NewRunRange(0, 10), 1, 0), nil, true),
+	} {
+		if _, err := PayloadHash(obj); err == nil {
+			t.Fatalf("expected an error hashing %T", obj)
+		}
+	}
+}
+
+func TestPayloadHashStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocdb-hash-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gai := NewPath("MUON", "Calib", "Gains")
+
+	// equal payloads stored with other IDs and metadata have the same hash,
+	// also after a round trip through files.
+	// The files are not compressed: groot may write ZLIB buffers it can not
+	// read back when they barely compress.
+	var hashes []string
+	for i, meta := range []*MetaData{
+		NewMetaData("tester", 0, "v5", "first"),
+		NewMetaData("someone else", 3, "v6", "second"),
+	} {
+		obj := newTestMap(30, i == 1)
+		want, err := PayloadHash(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fname := filepath.Join(dir, fmt.Sprintf("entry-%d.root", i))
+		err = writeUncompressed(fname, NewEntry(obj, NewID(gai, NewRunRange(int32(10*i), int32(10*i+9)), int32(i+1), 0), meta, true))
+		if err != nil {
+			t.Fatalf("could not store entry: %+v", err)
+		}
+		entry, err := ReadEntry(fname)
+		if err != nil {
+			t.Fatalf("could not load entry: %+v", err)
+		}
+		got, err := PayloadHash(entry.Object())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("hash modified by a round trip: got=%s, want=%s", got, want)
+		}
+		hashes = append(hashes, got)
+	}
+	if hashes[0] != hashes[1] {
+		t.Fatalf("different hashes for equal payloads: %q", hashes)
+	}
+}
+
+// writeUncompressed writes entry into a new, uncompressed, OCDB file.
+func writeUncompressed(fname string, entry *Entry) error {
+	f, err := groot.Create(fname, riofs.WithoutCompression())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = f.Put(EntryKey, entry)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,410 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IndexFile is the default name of the index file of a local storage,
+// stored at the top of its directory.
+const IndexFile = ".ocdb-index"
+
+const (
+	indexMagic   = "aligo/ocdb-index"
+	indexVersion = 3
+)
+
+var (
+	// ErrStaleIndex is returned when an index does not describe the current
+	// files of its storage, or was written with another version of the index format.
+	ErrStaleIndex = errors.New("ocdb: stale index")
+)
+
+// IndexEntry describes an OCDB file of a local storage.
+type IndexEntry struct {
+	File       string // file name, relative to the top directory of the storage
+	Path       string // path of the entry, from the file location
+	First      int32  // first run, from the file name
+	Last       int32  // last run, from the file name
+	Version    int32  // version, from the file name
+	SubVersion int32  // subversion, from the file name
+	Size       int64  // file size, in bytes
+	ModTime    int64  // file modification time, in nanoseconds since the Unix epoch
+	Err        string // error reading the file, if it could not be read as an entry
+
+	Class           string            // class of the payload
+	ObjectClassName string            // metadata object class name
+	Responsible     string            // metadata responsible
+	BeamPeriod      uint32            // metadata beam period
+	AliRootVersion  string            // metadata AliRoot version
+	Comment         string            // metadata comment
+	Properties      map[string]string // metadata string properties
+}
+
+// ID returns the ID of the indexed entry.
+func (e IndexEntry) ID() ID {
+	p, _ := ParsePath(e.Path)
+	return NewID(p, NewRunRange(e.First, e.Last), e.Version, e.SubVersion)
+}
+
+// Index is an index of the files of a local storage, holding their ID
+// and metadata, so the storage can be searched without scanning its
+// directories nor opening its files.
+type Index struct {
+	dir     string
+	entries []IndexEntry     // sorted by path, first run, version and subversion
+	paths   map[string][]ID  // IDs, by path
+	dirs    map[string]int64 // modification times of the level directories, by relative name
+}
+
+// IndexStats describes the changes made by an index update.
+type IndexStats struct {
+	Added     int     // number of new files
+	Updated   int     // number of modified files
+	Removed   int     // number of removed files
+	Unchanged int     // number of unmodified files
+	Errors    []error // new or modified files that could not be read
+}
+
+// NewIndex creates an empty index of the local storage rooted at dir.
+// Update must be called to index the files of the storage.
+func NewIndex(dir string) *Index {
+	return &Index{dir: dir, paths: make(map[string][]ID), dirs: make(map[string]int64)}
+}
+
+// Dir returns the top directory of the indexed storage.
+func (idx *Index) Dir() string { return idx.dir }
+
+// Entries returns the indexed files, sorted by path, first run, version and subversion.
+func (idx *Index) Entries() []IndexEntry { return idx.entries }
+
+// Update scans the directory of the storage and indexes new and modified files.
+// Files whose size and modification time did not change are not read again.
+// Files that cannot be read are indexed with their error, and are only read
+// again once modified.
+func (idx *Index) Update() (IndexStats, error) {
+	return idx.scan(true)
+}
+
+// Check compares the index with the files of the storage, without reading them
+// nor modifying the index.
+// The returned statistics count the files added, modified and removed since
+// the last update of the index.
+func (idx *Index) Check() (IndexStats, error) {
+	return idx.scan(false)
+}
+
+// Changed returns whether any file was added, modified or removed.
+func (stats IndexStats) Changed() bool {
+	return stats.Added+stats.Updated+stats.Removed > 0
+}
+
+// scan compares the index with the files of the storage, and indexes new and
+// modified files if update is true.
+func (idx *Index) scan(update bool) (IndexStats, error) {
+	var stats IndexStats
+
+	old := make(map[string]IndexEntry, len(idx.entries))
+	for _, e := range idx.entries {
+		old[e.File] = e
+	}
+
+	var (
+		entries []IndexEntry
+		dirs    = make(map[string]int64)
+	)
+	err := filepath.Walk(idx.dir, func(fname string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(idx.dir, fname)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if fi.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			if strings.Count(rel, "/") >= 3 {
+				return filepath.SkipDir
+			}
+			dirs[rel] = fi.ModTime().UnixNano()
+			return nil
+		}
+		if strings.Count(rel, "/") != 3 || strings.HasPrefix(fi.Name(), ".") {
+			return nil
+		}
+		id, err := ParseID(fname)
+		if err != nil {
+			return nil
+		}
+
+		if e, ok := old[rel]; ok {
+			delete(old, rel)
+			if e.Size == fi.Size() && e.ModTime == fi.ModTime().UnixNano() {
+				entries = append(entries, e)
+				stats.Unchanged++
+				return nil
+			}
+			stats.Updated++
+		} else {
+			stats.Added++
+		}
+		if !update {
+			return nil
+		}
+
+		e, err := newIndexEntry(rel, id, fname, fi)
+		if err != nil {
+			e.Err = err.Error()
+			stats.Errors = append(stats.Errors, err)
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return stats, errors.Wrapf(err, "ocdb: could not index %q", idx.dir)
+	}
+	stats.Removed = len(old)
+
+	if update {
+		idx.entries = entries
+		idx.dirs = dirs
+		idx.sort()
+	}
+	return stats, nil
+}
+
+// checkDirs compares the modification times of the level directories of the
+// storage with the ones recorded by the last update of the index, without
+// looking at the files of the storage.
+// Adding, removing or renaming files and directories modifies the directory
+// holding them, but rewriting a file in place does not.
+// The top directory, which holds the index file, is only checked for new
+// directories.
+func (idx *Index) checkDirs() error {
+	fis, err := ioutil.ReadDir(idx.dir)
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: could not check index of %q", idx.dir)
+	}
+	for _, fi := range fis {
+		if _, ok := idx.dirs[fi.Name()]; fi.IsDir() && !ok {
+			return errors.Wrapf(ErrStaleIndex, "ocdb: directory %q added to %q", fi.Name(), idx.dir)
+		}
+	}
+
+	for rel, mtime := range idx.dirs {
+		fi, err := os.Stat(filepath.Join(idx.dir, filepath.FromSlash(rel)))
+		switch {
+		case os.IsNotExist(err):
+			return errors.Wrapf(ErrStaleIndex, "ocdb: directory %q removed from %q", rel, idx.dir)
+		case err != nil:
+			return errors.Wrapf(err, "ocdb: could not check index of %q", idx.dir)
+		case fi.ModTime().UnixNano() != mtime:
+			return errors.Wrapf(ErrStaleIndex, "ocdb: directory %q of %q modified", rel, idx.dir)
+		}
+	}
+	return nil
+}
+
+func newIndexEntry(rel string, id ID, fname string, fi os.FileInfo) (IndexEntry, error) {
+	e := IndexEntry{
+		File:       rel,
+		Path:       id.path.path,
+		First:      id.runs.First,
+		Last:       id.runs.Last,
+		Version:    id.vers,
+		SubVersion: id.subvers,
+		Size:       fi.Size(),
+		ModTime:    fi.ModTime().UnixNano(),
+	}
+
+	entry, err := ReadEntry(fname)
+	if err != nil {
+		return e, err
+	}
+	if entry.obj != nil {
+		e.Class = entry.obj.Class()
+	}
+	if meta := entry.meta; meta != nil {
+		e.ObjectClassName = meta.class
+		e.Responsible = meta.resp
+		e.BeamPeriod = meta.beam
+		e.AliRootVersion = meta.vers
+		e.Comment = meta.comment
+		e.Properties = meta.Properties()
+	}
+	return e, nil
+}
+
+// add indexes the named file, holding entry, replacing any previous index entry for it.
+func (idx *Index) add(fname string, entry *Entry) error {
+	rel, err := filepath.Rel(idx.dir, fname)
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: could not index %q", fname)
+	}
+	rel = filepath.ToSlash(rel)
+
+	fi, err := os.Stat(fname)
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: could not index %q", fname)
+	}
+
+	e, err := newIndexEntry(rel, entry.id, fname, fi)
+	if err != nil {
+		return err
+	}
+
+	// the directories of the entry may have been created or modified.
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		fi, err := os.Stat(filepath.Join(idx.dir, filepath.FromSlash(dir)))
+		if err != nil {
+			return errors.Wrapf(err, "ocdb: could not index %q", fname)
+		}
+		idx.dirs[dir] = fi.ModTime().UnixNano()
+	}
+
+	for i := range idx.entries {
+		if idx.entries[i].File == rel {
+			idx.entries[i] = e
+			idx.sort()
+			return nil
+		}
+	}
+	idx.entries = append(idx.entries, e)
+	idx.sort()
+	return nil
+}
+
+func (idx *Index) sort() {
+	sort.Slice(idx.entries, func(i, j int) bool {
+		ei, ej := idx.entries[i], idx.entries[j]
+		switch {
+		case ei.Path != ej.Path:
+			return ei.Path < ej.Path
+		case ei.First != ej.First:
+			return ei.First < ej.First
+		case ei.Version != ej.Version:
+			return ei.Version < ej.Version
+		default:
+			return ei.SubVersion < ej.SubVersion
+		}
+	})
+
+	idx.paths = make(map[string][]ID)
+	for _, e := range idx.entries {
+		idx.paths[e.Path] = append(idx.paths[e.Path], e.ID())
+	}
+}
+
+// ids returns the IDs of the entries stored under the exact path p.
+func (idx *Index) ids(p Path) []ID {
+	return idx.paths[p.path]
+}
+
+// list returns the IDs of the entries stored under any path matching p.
+func (idx *Index) list(p Path) []ID {
+	var ids []ID
+	for _, e := range idx.entries {
+		id := e.ID()
+		if p.Comprises(id.path) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+type indexFile struct {
+	Magic   string
+	Version int
+	Entries []IndexEntry
+	Dirs    map[string]int64
+}
+
+// OpenIndex reads the index of the local storage rooted at dir, from its
+// default index file.
+// OpenIndex returns a nil index, and no error, if the storage has no index file.
+func OpenIndex(dir string) (*Index, error) {
+	fname := filepath.Join(dir, IndexFile)
+	_, err := os.Stat(fname)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return ReadIndex(fname, dir)
+}
+
+// ReadIndex reads the index of the local storage rooted at dir, from the named file.
+// Index files only record file names relative to the top directory of their
+// storage, so that storages can be moved along with their index.
+// An index file written with another version of the index format is reported
+// as an ErrStaleIndex error.
+func ReadIndex(fname, dir string) (*Index, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: could not open index file")
+	}
+	defer f.Close()
+
+	var v indexFile
+	err = gob.NewDecoder(f).Decode(&v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: could not decode index file %q", fname)
+	}
+	if v.Magic != indexMagic {
+		return nil, errors.Errorf("ocdb: %q is not an OCDB index file", fname)
+	}
+	if v.Version != indexVersion {
+		return nil, errors.Wrapf(ErrStaleIndex, "ocdb: index file %q has unsupported version %d", fname, v.Version)
+	}
+
+	idx := &Index{dir: dir, entries: v.Entries, dirs: v.Dirs}
+	if idx.dirs == nil {
+		idx.dirs = make(map[string]int64)
+	}
+	idx.sort()
+	return idx, nil
+}
+
+// Write writes the index to the named file, replacing it atomically.
+func (idx *Index) Write(fname string) error {
+	tmp := fname + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: could not create index file")
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	err = gob.NewEncoder(f).Encode(indexFile{
+		Magic:   indexMagic,
+		Version: indexVersion,
+		Entries: idx.entries,
+		Dirs:    idx.dirs,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: could not encode index")
+	}
+
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: could not close index file")
+	}
+
+	err = os.Rename(tmp, fname)
+	if err != nil {
+		return errors.Wrapf(err, "ocdb: could not write index file")
+	}
+	return nil
+}
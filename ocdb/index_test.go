@@ -0,0 +1,391 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestIndex(t *testing.T) {
+	var (
+		ped = NewPath("MUON", "Calib", "Pedestals")
+		gai = NewPath("MUON", "Calib", "Gains")
+		grp = NewPath("GRP", "GRP", "Data")
+	)
+	db, cleanup := newTestLocal(t, []testPut{
+		{NewID(ped, NewRunRange(0, Infinity), -1, -1), "ped-v1"},
+		{NewID(grp, NewRunRange(150, 150), -1, -1), "grp-v1"},
+	})
+	defer cleanup()
+
+	idx, err := OpenIndex(db.Dir())
+	if err != nil || idx != nil {
+		t.Fatalf("unexpected index for storage without index file: idx=%v, err=%v", idx, err)
+	}
+
+	idx = NewIndex(db.Dir())
+	stats, err := idx.Update()
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+	if got, want := stats, (IndexStats{Added: 2}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid stats: got=%+v, want=%+v", got, want)
+	}
+	db.SetIndex(idx)
+
+	// add entries through the index.
+	for _, p := range []testPut{
+		{NewID(ped, NewRunRange(100, 200), -1, -1), "ped-v2"},
+		{NewID(gai, NewRunRange(0, 99), -1, -1), "gai-v1"},
+		{NewID(ped, NewRunRange(100, 200), 2, -1), "ped-v2s1"},
+	} {
+		_, err := db.Put(p.entry())
+		if err != nil {
+			t.Fatalf("could not store %v: %+v", p.id, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		pattern string
+		want    []string
+	}{
+		{
+			pattern: "*",
+			want: []string{
+				"GRP/GRP/Data/Run150_150_v1_s0.root",
+				"MUON/Calib/Gains/Run0_99_v1_s0.root",
+				"MUON/Calib/Pedestals/Run0_999999999_v1_s0.root",
+				"MUON/Calib/Pedestals/Run100_200_v2_s0.root",
+				"MUON/Calib/Pedestals/Run100_200_v2_s1.root",
+			},
+		},
+		{
+			pattern: "MUON/*/Pedestals",
+			want: []string{
+				"MUON/Calib/Pedestals/Run0_999999999_v1_s0.root",
+				"MUON/Calib/Pedestals/Run100_200_v2_s0.root",
+				"MUON/Calib/Pedestals/Run100_200_v2_s1.root",
+			},
+		},
+		{
+			pattern: "MUON/Calib/Gains",
+			want:    []string{"MUON/Calib/Gains/Run0_99_v1_s0.root"},
+		},
+		{
+			pattern: "TPC/*",
+		},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			p, err := ParsePath(tc.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := idNames(idx.list(p)); !equalStrings(got, tc.want) {
+				t.Fatalf("invalid indexed IDs:\ngot= %q\nwant=%q", got, tc.want)
+			}
+
+			// the index must agree with a scan of the directories.
+			db.SetIndex(nil)
+			ids, err := db.List(tc.pattern)
+			db.SetIndex(idx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := idNames(ids); !equalStrings(got, tc.want) {
+				t.Fatalf("invalid scanned IDs:\ngot= %q\nwant=%q", got, tc.want)
+			}
+		})
+	}
+
+	entry, err := db.Get(ped.path, 150)
+	if err != nil {
+		t.Fatalf("could not get entry through index: %+v", err)
+	}
+	if got, want := payload(entry), "ped-v2s1"; got != want {
+		t.Fatalf("invalid payload: got=%q, want=%q", got, want)
+	}
+
+	var e IndexEntry
+	for _, v := range idx.Entries() {
+		if v.File == "MUON/Calib/Gains/Run0_99_v1_s0.root" {
+			e = v
+		}
+	}
+	want := IndexEntry{
+		File: "MUON/Calib/Gains/Run0_99_v1_s0.root", Path: gai.path,
+		First: 0, Last: 99, Version: 1, SubVersion: 0,
+		Class: "TObjString", ObjectClassName: "TObjString", Responsible: "tester", AliRootVersion: "v5", Comment: "gai-v1",
+		Properties: map[string]string{},
+	}
+	e.Size, e.ModTime = 0, 0
+	if !reflect.DeepEqual(e, want) {
+		t.Fatalf("invalid index entry:\ngot= %+v\nwant=%+v", e, want)
+	}
+
+	// round-trip through the default index file.
+	fname := filepath.Join(db.Dir(), IndexFile)
+	err = idx.Write(fname)
+	if err != nil {
+		t.Fatalf("could not write index: %+v", err)
+	}
+
+	stats, err = idx.Update()
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+	if got, want := stats, (IndexStats{Unchanged: 5}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid stats: got=%+v, want=%+v", got, want)
+	}
+
+	err = os.Remove(db.Filename(NewID(gai, NewRunRange(0, 99), 1, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := OpenIndex(db.Dir())
+	if err != nil {
+		t.Fatalf("could not open index: %+v", err)
+	}
+	if !reflect.DeepEqual(got.Entries(), idx.Entries()) {
+		t.Fatalf("index round-trip failed")
+	}
+	stats, err = got.Update()
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+	if got, want := stats, (IndexStats{Removed: 1, Unchanged: 4}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid stats: got=%+v, want=%+v", got, want)
+	}
+
+	db2, err := NewLocal(db.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the index file does not describe the removed file.
+	err = db2.LoadIndex("")
+	if errors.Cause(err) != ErrStaleIndex {
+		t.Fatalf("invalid error loading a stale index: %+v", err)
+	}
+	if db2.Index() != nil {
+		t.Fatalf("stale index loaded")
+	}
+	stats, err = idx.Check()
+	if err != nil {
+		t.Fatalf("could not check index: %+v", err)
+	}
+	if got, want := stats, (IndexStats{Removed: 1, Unchanged: 4}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid check stats: got=%+v, want=%+v", got, want)
+	}
+	if got, want := len(idx.Entries()), 5; got != want {
+		t.Fatalf("index modified by check: got=%d entries, want=%d", got, want)
+	}
+
+	err = got.Write(fname)
+	if err != nil {
+		t.Fatalf("could not write index: %+v", err)
+	}
+	err = db2.LoadIndex("")
+	if err != nil {
+		t.Fatalf("could not load index: %+v", err)
+	}
+	if db2.Index() == nil {
+		t.Fatalf("up-to-date index not loaded")
+	}
+
+	// entries stored through the index keep it up to date.
+	_, err = db2.Put(testPut{NewID(NewPath("TPC", "Calib", "Gains"), NewRunRange(0, 10), -1, -1), "tpc-v1"}.entry())
+	if err != nil {
+		t.Fatalf("could not store entry: %+v", err)
+	}
+	err = db2.Index().Write(fname)
+	if err != nil {
+		t.Fatalf("could not write index: %+v", err)
+	}
+	db3, err := NewLocal(db.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db3.LoadIndex("")
+	if err != nil {
+		t.Fatalf("could not load index: %+v", err)
+	}
+	if got, want := len(db3.Index().Entries()), 5; got != want {
+		t.Fatalf("invalid number of indexed files: got=%d, want=%d", got, want)
+	}
+}
+
+func TestIndexStale(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	for _, tc := range []struct {
+		name   string
+		modify func(dir string) error
+	}{
+		{"added-file", func(dir string) error {
+			return ioutil.WriteFile(filepath.Join(dir, "MUON/Calib/Pedestals/Run10_20_v1_s0.root"), nil, 0644)
+		}},
+		{"added-path", func(dir string) error {
+			return os.MkdirAll(filepath.Join(dir, "MUON/Calib/Gains"), 0755)
+		}},
+		{"added-level0", func(dir string) error {
+			return os.MkdirAll(filepath.Join(dir, "TPC"), 0755)
+		}},
+		{"removed-path", func(dir string) error {
+			return os.RemoveAll(filepath.Join(dir, "MUON/Calib/Pedestals"))
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, cleanup := newTestLocal(t, []testPut{
+				{NewID(ped, NewRunRange(0, Infinity), -1, -1), "ped-v1"},
+			})
+			defer cleanup()
+
+			idx := NewIndex(db.Dir())
+			_, err := idx.Update()
+			if err != nil {
+				t.Fatalf("could not update index: %+v", err)
+			}
+			err = idx.Write(filepath.Join(db.Dir(), IndexFile))
+			if err != nil {
+				t.Fatalf("could not write index: %+v", err)
+			}
+
+			err = db.LoadIndex("")
+			if err != nil {
+				t.Fatalf("could not load index: %+v", err)
+			}
+			db.SetIndex(nil)
+
+			err = tc.modify(db.Dir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = db.LoadIndex("")
+			if errors.Cause(err) != ErrStaleIndex {
+				t.Fatalf("invalid error loading a stale index: %+v", err)
+			}
+			if db.Index() != nil {
+				t.Fatalf("stale index loaded")
+			}
+		})
+	}
+}
+
+func TestIndexUnreadable(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	db, cleanup := newTestLocal(t, []testPut{
+		{NewID(ped, NewRunRange(0, 99), -1, -1), "ped-v1"},
+	})
+	defer cleanup()
+
+	bad := db.Filename(NewID(ped, NewRunRange(100, 199), 1, 0))
+	err := ioutil.WriteFile(bad, []byte("not a ROOT file"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := NewIndex(db.Dir())
+	stats, err := idx.Update()
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+	if stats.Added != 2 || len(stats.Errors) != 1 {
+		t.Fatalf("invalid stats: got=%+v, want 2 added files and 1 error", stats)
+	}
+
+	var e IndexEntry
+	for _, v := range idx.Entries() {
+		if v.File == "MUON/Calib/Pedestals/Run100_199_v1_s0.root" {
+			e = v
+		}
+	}
+	if e.Err == "" || e.Size != int64(len("not a ROOT file")) || e.ModTime == 0 {
+		t.Fatalf("invalid index entry for unreadable file: %+v", e)
+	}
+
+	// unreadable files are not read again, unless modified.
+	stats, err = idx.Update()
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+	if got, want := stats, (IndexStats{Unchanged: 2}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid stats: got=%+v, want=%+v", got, want)
+	}
+
+	// the unreadable file is listed, as by a scan of the directories.
+	db.SetIndex(idx)
+	ids, err := db.List(ped.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"MUON/Calib/Pedestals/Run0_99_v1_s0.root",
+		"MUON/Calib/Pedestals/Run100_199_v1_s0.root",
+	}
+	if got := idNames(ids); !equalStrings(got, want) {
+		t.Fatalf("invalid indexed IDs:\ngot= %q\nwant=%q", got, want)
+	}
+}
+
+func TestIndexMoved(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	db, cleanup := newTestLocal(t, []testPut{
+		{NewID(ped, NewRunRange(0, 99), -1, -1), "ped-v1"},
+	})
+	defer cleanup()
+
+	idx := NewIndex(db.Dir())
+	_, err := idx.Update()
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+	err = idx.Write(filepath.Join(db.Dir(), IndexFile))
+	if err != nil {
+		t.Fatalf("could not write index: %+v", err)
+	}
+
+	dir := db.Dir() + "-moved"
+	err = os.Rename(db.Dir(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	idx, err = OpenIndex(dir)
+	if err != nil {
+		t.Fatalf("could not open index: %+v", err)
+	}
+	if got, want := idx.Dir(), dir; got != want {
+		t.Fatalf("invalid index directory: got=%q, want=%q", got, want)
+	}
+	stats, err := idx.Update()
+	if err != nil {
+		t.Fatalf("could not update index: %+v", err)
+	}
+	if got, want := stats, (IndexStats{Unchanged: 1}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid stats: got=%+v, want=%+v", got, want)
+	}
+
+	moved, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = moved.LoadIndex("")
+	if err != nil {
+		t.Fatalf("could not load index: %+v", err)
+	}
+	entry, err := moved.Get(ped.path, 42)
+	if err != nil {
+		t.Fatalf("could not get entry through index: %+v", err)
+	}
+	if got, want := payload(entry), "ped-v1"; got != want {
+		t.Fatalf("invalid payload: got=%q, want=%q", got, want)
+	}
+}
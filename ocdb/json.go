@@ -0,0 +1,205 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/rdict"
+	"go-hep.org/x/hep/groot/root"
+)
+
+// MarshalJSON implements json.Marshaler.
+// A path is encoded as its name, e.g. "MUON/Calib/Pedestals".
+func (p Path) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.path)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (rr RunRange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		First int32 `json:"first"`
+		Last  int32 `json:"last"`
+	}{rr.First, rr.Last})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Path        Path     `json:"path"`
+		Runs        RunRange `json:"runs"`
+		Version     int32    `json:"version"`
+		SubVersion  int32    `json:"subversion"`
+		LastStorage string   `json:"lastStorage,omitempty"`
+	}{id.path, id.runs, id.vers, id.subvers, id.last})
+}
+
+// MarshalJSON implements json.Marshaler.
+// Only string properties are encoded.
+func (meta *MetaData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Class          string            `json:"class"`
+		Responsible    string            `json:"responsible"`
+		BeamPeriod     uint32            `json:"beamPeriod"`
+		AliRootVersion string            `json:"aliRootVersion"`
+		Comment        string            `json:"comment"`
+		Properties     map[string]string `json:"properties"`
+	}{meta.class, meta.resp, meta.beam, meta.vers, meta.comment, meta.Properties()})
+}
+
+// MarshalJSON implements json.Marshaler.
+// The payload of the entry is encoded with MarshalObject.
+func (entry *Entry) MarshalJSON() ([]byte, error) {
+	obj, err := MarshalObject(entry.obj)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		ID       ID              `json:"id"`
+		MetaData *MetaData       `json:"metadata"`
+		Owner    bool            `json:"owner"`
+		Object   json.RawMessage `json:"object"`
+	}{entry.id, entry.meta, entry.owner, obj})
+}
+
+// MarshalObject returns the JSON encoding of a ROOT object.
+//
+// Objects implementing json.Marshaler are encoded in their own, structured, form.
+// Other objects are encoded as their class name and a generic dump of their fields:
+//
+//	{"class": "TObjString", "fields": {"str": "..."}}
+func MarshalObject(obj root.Object) ([]byte, error) {
+	if obj == nil {
+		return []byte("null"), nil
+	}
+	if m, ok := obj.(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+	return json.Marshal(struct {
+		Class  string      `json:"class"`
+		Fields interface{} `json:"fields"`
+	}{obj.Class(), dumpObject(obj)})
+}
+
+var tobject = reflect.TypeOf(rbase.Object{})
+
+// maxDumpDepth is the maximum depth of nested values in a generic dump.
+const maxDumpDepth = 32
+
+// dumpObject returns a generic representation of obj, suitable for JSON encoding.
+func dumpObject(obj root.Object) interface{} {
+	rv := reflect.ValueOf(obj)
+	if o, ok := obj.(*rdict.Object); ok && o != nil {
+		// objects decoded from their StreamerInfo hold their fields
+		// in a generated struct.
+		rv = rv.Elem().FieldByName("v")
+	}
+	return dumpValue(rv, 0)
+}
+
+func dumpValue(rv reflect.Value, depth int) interface{} {
+	if !rv.IsValid() || depth > maxDumpDepth {
+		return nil
+	}
+
+	if rv.CanInterface() {
+		switch v := rv.Interface().(type) {
+		case json.Marshaler:
+			if rv.Kind() != reflect.Ptr || !rv.IsNil() {
+				return v
+			}
+		case root.Object:
+			if rv.Kind() == reflect.Ptr && rv.IsNil() {
+				return nil
+			}
+			if depth > 0 {
+				return struct {
+					Class  string      `json:"class"`
+					Fields interface{} `json:"fields"`
+				}{v.Class(), dumpObject(v)}
+			}
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		v := rv.Float()
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// not representable in JSON.
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return v
+	case reflect.String:
+		return rv.String()
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return dumpValue(rv.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return []interface{}{}
+		}
+		vs := make([]interface{}, rv.Len())
+		for i := range vs {
+			vs[i] = dumpValue(rv.Index(i), depth+1)
+		}
+		return vs
+	case reflect.Map:
+		vs := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			vs[dumpKey(k)] = dumpValue(rv.MapIndex(k), depth+1)
+		}
+		return vs
+	case reflect.Struct:
+		vs := make(map[string]interface{}, rv.NumField())
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			ft := rt.Field(i)
+			name := ft.Name
+			if tag := ft.Tag.Get("groot"); tag != "" {
+				name = strings.Split(tag, ",")[0]
+			}
+			if name == "BASE-TObject" || ft.Type == tobject {
+				continue
+			}
+			vs[name] = dumpValue(rv.Field(i), depth+1)
+		}
+		return vs
+	}
+	return nil
+}
+
+func dumpKey(k reflect.Value) string {
+	if k.CanInterface() {
+		if v, ok := k.Interface().(interface{ String() string }); ok {
+			return v.String()
+		}
+	}
+	b, err := json.Marshal(dumpValue(k, 0))
+	if err != nil {
+		return k.String()
+	}
+	return strings.Trim(string(b), `"`)
+}
+
+var (
+	_ json.Marshaler = (*Path)(nil)
+	_ json.Marshaler = (*RunRange)(nil)
+	_ json.Marshaler = (*ID)(nil)
+	_ json.Marshaler = (*MetaData)(nil)
+	_ json.Marshaler = (*Entry)(nil)
+)
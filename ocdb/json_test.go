@@ -0,0 +1,61 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+func TestEntryMarshalJSON(t *testing.T) {
+	meta := NewMetaData("tester", 3, "v5-09-38", "pedestals")
+	meta.SetProperty("RunUsed", "297624")
+	meta.SetProperty("Detector", "MUON")
+
+	id := NewID(NewPath("MUON", "Calib", "Pedestals"), NewRunRange(297624, Infinity), 2, 1)
+	id.last = "local"
+
+	for _, tc := range []struct {
+		name   string
+		entry  *Entry
+		golden string
+	}{
+		{"entry", NewEntry(rbase.NewObjString("payload"), id, meta, true), "entry.json"},
+		{"empty", NewEntry(nil, NewID(NewPath("A", "B", "C"), NewRunRange(1, 2), -1, -1), nil, false), "entry-empty.json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := json.Marshal(tc.entry)
+			if err != nil {
+				t.Fatalf("could not encode entry: %+v", err)
+			}
+			var got bytes.Buffer
+			err = json.Indent(&got, raw, "", "  ")
+			if err != nil {
+				t.Fatalf("invalid JSON %s: %+v", raw, err)
+			}
+			got.WriteString("\n")
+
+			fname := filepath.Join("testdata", tc.golden)
+			if *update {
+				err = ioutil.WriteFile(fname, got.Bytes(), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Fatalf("invalid JSON:\ngot:\n%s\nwant:\n%s", got.Bytes(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Local is an OCDB storage backed by a local directory, laid out as:
+//
+//	<dir>/<Level0>/<Level1>/<Level2>/Run<first>_<last>_v<version>_s<subversion>.root
+//
+// Local is the equivalent of AliRoot's AliCDBLocal.
+type Local struct {
+	dir  string
+	idx  *Index           // index of the storage, used instead of scanning directories
+	warn func(msg string) // reports warnings, if not nil
+}
+
+// NewLocal creates a new local storage rooted at dir.
+func NewLocal(dir string) (*Local, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: could not access local storage")
+	}
+	if !fi.IsDir() {
+		return nil, errors.Errorf("ocdb: local storage %q is not a directory", dir)
+	}
+	return &Local{dir: dir}, nil
+}
+
+// Dir returns the base directory of the local storage.
+func (db *Local) Dir() string { return db.dir }
+
+// SetIndex makes the local storage look entries up in idx instead of
+// scanning its directories.
+// Entries stored with Put are added to idx, which must describe the storage.
+// A nil index restores directory scans.
+func (db *Local) SetIndex(idx *Index) { db.idx = idx }
+
+// LoadIndex makes the local storage use the index stored in the named file,
+// or in its default index file if fname is empty.
+// Without default index file, the storage keeps scanning its directories.
+//
+// The index is checked against the modification times of the directories of
+// the storage, whose files are neither listed nor read.
+// A stale index, describing directories whose files were since added or
+// removed, or written with another version of the index format, is not used:
+// LoadIndex then returns an error whose cause is ErrStaleIndex, and the
+// storage keeps scanning its directories.
+func (db *Local) LoadIndex(fname string) error {
+	var (
+		idx *Index
+		err error
+	)
+	switch fname {
+	case "":
+		idx, err = OpenIndex(db.dir)
+	default:
+		idx, err = ReadIndex(fname, db.dir)
+	}
+	if err != nil || idx == nil {
+		return err
+	}
+
+	err = idx.checkDirs()
+	if err != nil {
+		return err
+	}
+
+	db.idx = idx
+	return nil
+}
+
+// SetWarner sets the function called with the warnings of the local storage,
+// such as a change of run range with respect to the previous version of a
+// stored entry.
+// Warnings are discarded if f is nil, the default.
+func (db *Local) SetWarner(f func(msg string)) { db.warn = f }
+
+// Index returns the index used by the local storage, if any.
+func (db *Local) Index() *Index { return db.idx }
+
+// Filename returns the name of the file holding the entry identified by id.
+func (db *Local) Filename(id ID) string {
+	return filepath.Join(db.dir, filepath.FromSlash(id.path.path), FormatFilename(id.runs, id.vers, id.subvers))
+}
+
+// Get returns the entry stored under path, valid for the provided run.
+func (db *Local) Get(path string, run int32) (*Entry, error) {
+	id, err := db.GetID(path, run)
+	if err != nil {
+		return nil, err
+	}
+	return db.Load(id)
+}
+
+// GetAll returns the entries stored under any path matching pattern,
+// valid for the provided run.
+// Paths without any entry valid for that run are skipped.
+func (db *Local) GetAll(pattern string, run int32) ([]*Entry, error) {
+	ids, err := db.List(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, ids := range splitIDs(ids) {
+		query := NewID(ids[0].path, NewRunRange(run, run), -1, -1)
+		id, err := ResolveID(ids, query)
+		if err != nil {
+			if errors.Cause(err) == ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		entry, err := db.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetID returns the ID of the entry stored under path, valid for the provided run.
+// The highest version valid for that run is selected.
+func (db *Local) GetID(path string, run int32) (ID, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return ID{}, err
+	}
+	if p.wildcard {
+		return ID{}, errors.Errorf("ocdb: path %q must not contain wildcards", path)
+	}
+
+	ids, err := db.ids(p)
+	if err != nil {
+		return ID{}, err
+	}
+
+	return ResolveID(ids, NewID(p, NewRunRange(run, run), -1, -1))
+}
+
+// List returns the IDs of all the entries stored under any path matching pattern.
+// IDs are sorted by path, first run, version and subversion.
+func (db *Local) List(pattern string) ([]ID, error) {
+	p, err := ParsePath(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if db.idx != nil {
+		ids := db.idx.list(p)
+		sortIDs(ids)
+		return ids, nil
+	}
+
+	var ids []ID
+	lvls0, err := db.levels(p.lvl0, db.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, lvl0 := range lvls0 {
+		lvls1, err := db.levels(p.lvl1, db.dir, lvl0)
+		if err != nil {
+			return nil, err
+		}
+		for _, lvl1 := range lvls1 {
+			lvls2, err := db.levels(p.lvl2, db.dir, lvl0, lvl1)
+			if err != nil {
+				return nil, err
+			}
+			for _, lvl2 := range lvls2 {
+				vs, err := db.ids(NewPath(lvl0, lvl1, lvl2))
+				if err != nil {
+					return nil, err
+				}
+				ids = append(ids, vs...)
+			}
+		}
+	}
+
+	sortIDs(ids)
+	return ids, nil
+}
+
+// Put stores entry into the local storage, following the rules of AliCDBLocal:
+//   - if the entry ID has no version, it is assigned the highest version
+//     found among the files overlapping its run range, plus one, and a zero subversion,
+//   - if the entry ID has a version, it is assigned the highest subversion
+//     found for that version among the files overlapping its run range, plus one.
+//
+// Put refuses to store again an entry transferred from a grid storage.
+// A change of run range with respect to the previous version is reported as
+// a warning but does not prevent storing the entry.
+//
+// On success, the entry ID is updated with its new version, subversion and
+// last storage, and returned.
+func (db *Local) Put(entry *Entry) (ID, error) {
+	id := entry.id
+	if !id.path.valid || id.path.wildcard {
+		return id, errors.Errorf("ocdb: invalid path %q for entry", id.path.path)
+	}
+	if !id.runs.isSpecified() {
+		return id, errors.Errorf("ocdb: invalid run range %v for entry", id.runs)
+	}
+	if entry.obj == nil {
+		return id, errors.Errorf("ocdb: entry %q has no object", id.path.path)
+	}
+
+	dir := filepath.Join(db.dir, filepath.FromSlash(id.path.path))
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return id, errors.Wrapf(err, "ocdb: could not create directory %q", dir)
+	}
+
+	ids, err := db.ids(id.path)
+	if err != nil {
+		return id, err
+	}
+
+	id, warning, err := prepareID(id, ids)
+	if err != nil {
+		return id, err
+	}
+	if warning != "" && db.warn != nil {
+		db.warn(warning)
+	}
+	id.last = "local"
+
+	meta := NewMetaData("", 0, "", "")
+	if entry.meta != nil {
+		meta = entry.meta.Clone()
+	}
+	meta.class = entry.obj.Class()
+	entry.meta = meta
+	entry.id = id
+
+	fname := db.Filename(id)
+	err = WriteEntry(fname, entry)
+	if err != nil {
+		return id, err
+	}
+
+	if db.idx != nil {
+		err = db.idx.add(fname, entry)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	return id, nil
+}
+
+// prepareID assigns the version and subversion of an entry about to be
+// stored in a storage already holding ids, as AliCDBLocal::PrepareId does.
+// The returned warning reports a change of run range with respect to the
+// previous version, if any.
+func prepareID(id ID, ids []ID) (ID, string, error) {
+	var (
+		lastRuns    = NewRunRange(-1, -1)
+		lastVers    = int32(0)
+		lastSubVers = int32(-1)
+	)
+
+	switch {
+	case id.vers < 0:
+		for _, v := range ids {
+			if !v.runs.Overlaps(id.runs) {
+				continue
+			}
+			switch {
+			case v.vers > lastVers:
+				lastVers = v.vers
+				lastSubVers = v.subvers
+				lastRuns = v.runs
+			case v.vers == lastVers && v.subvers > lastSubVers:
+				lastSubVers = v.subvers
+				lastRuns = v.runs
+			}
+		}
+		id.vers = lastVers + 1
+		id.subvers = 0
+	default:
+		lastVers = id.vers
+		for _, v := range ids {
+			if v.runs.Overlaps(id.runs) && v.vers == id.vers && v.subvers > lastSubVers {
+				lastSubVers = v.subvers
+				lastRuns = v.runs
+			}
+		}
+		id.subvers = lastSubVers + 1
+	}
+
+	if strings.Contains(strings.ToLower(id.last), "grid") && id.subvers > 0 {
+		return id, "", errors.Errorf(
+			"ocdb: grid to local storage error: local object with version v%d_s%d found, "+
+				"this object has already been transferred from grid (check v%d_s0)",
+			id.vers, id.subvers-1, id.vers,
+		)
+	}
+
+	var warning string
+	if !lastRuns.IsAnyRange() && !lastRuns.Equal(id.runs) {
+		warning = fmt.Sprintf(
+			"ocdb: %s: run range modified w.r.t. previous version (Run%d_%d_v%d_s%d)",
+			id.path.path, lastRuns.First, lastRuns.Last, lastVers, lastSubVers,
+		)
+	}
+
+	return id, warning, nil
+}
+
+// Load returns the entry exactly identified by id.
+func (db *Local) Load(id ID) (*Entry, error) {
+	fname := db.Filename(id)
+	_, err := os.Stat(fname)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrapf(ErrNotFound, "ocdb: no file %q", fname)
+		}
+		return nil, err
+	}
+	return ReadEntry(fname)
+}
+
+// levels returns the names of the sub-directories of dir matching lvl.
+func (db *Local) levels(lvl string, dir ...string) ([]string, error) {
+	if lvl != "*" {
+		fi, err := os.Stat(filepath.Join(append(dir, lvl)...))
+		if err != nil || !fi.IsDir() {
+			return nil, nil
+		}
+		return []string{lvl}, nil
+	}
+
+	fis, err := ioutil.ReadDir(filepath.Join(dir...))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "ocdb: could not read directory")
+	}
+
+	var lvls []string
+	for _, fi := range fis {
+		if !fi.IsDir() || !isWord(fi.Name()) {
+			continue
+		}
+		lvls = append(lvls, fi.Name())
+	}
+	return lvls, nil
+}
+
+// ids returns the IDs of all the files stored under the exact path p.
+func (db *Local) ids(p Path) ([]ID, error) {
+	if db.idx != nil {
+		return append([]ID(nil), db.idx.ids(p)...), nil
+	}
+
+	fis, err := ioutil.ReadDir(filepath.Join(db.dir, filepath.FromSlash(p.path)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "ocdb: could not read directory")
+	}
+
+	var ids []ID
+	for _, fi := range fis {
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		runs, vers, subvers, err := ParseFilename(fi.Name())
+		if err != nil {
+			continue
+		}
+		ids = append(ids, NewID(p, runs, vers, subvers))
+	}
+	return ids, nil
+}
+
+// splitIDs splits sorted ids into groups of IDs sharing the same path.
+func splitIDs(ids []ID) [][]ID {
+	var groups [][]ID
+	for i := 0; i < len(ids); {
+		j := i + 1
+		for j < len(ids) && ids[j].path.path == ids[i].path.path {
+			j++
+		}
+		groups = append(groups, ids[i:j])
+		i = j
+	}
+	return groups
+}
+
+var (
+	_ Storage = (*Local)(nil)
+)
@@ -0,0 +1,395 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"go-hep.org/x/hep/groot/rbase"
+)
+
+// newTestLocal creates a local storage in a temporary directory, filled
+// by storing entries with the provided IDs and payloads in order.
+func newTestLocal(t *testing.T, puts []testPut) (*Local, func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "ocdb-local-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	db, err := NewLocal(dir)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	for _, p := range puts {
+		_, err := db.Put(p.entry())
+		if err != nil {
+			cleanup()
+			t.Fatalf("could not store %v: %+v", p.id, err)
+		}
+	}
+
+	return db, cleanup
+}
+
+type testPut struct {
+	id      ID
+	payload string
+}
+
+func (p testPut) entry() *Entry {
+	return NewEntry(rbase.NewObjString(p.payload), p.id, NewMetaData("tester", 0, "v5", p.payload), true)
+}
+
+// payload returns the string payload of entry.
+func payload(entry *Entry) string {
+	return entry.Object().(*rbase.ObjString).String()
+}
+
+func TestLocalGet(t *testing.T) {
+	var (
+		ped = NewPath("MUON", "Calib", "Pedestals")
+		gai = NewPath("MUON", "Calib", "Gains")
+		grp = NewPath("GRP", "GRP", "Data")
+	)
+	db, cleanup := newTestLocal(t, []testPut{
+		{NewID(ped, NewRunRange(0, Infinity), -1, -1), "ped-v1"},
+		{NewID(ped, NewRunRange(100, 200), -1, -1), "ped-v2"},
+		{NewID(ped, NewRunRange(100, 200), 2, -1), "ped-v2s1"},
+		{NewID(gai, NewRunRange(0, 99), -1, -1), "gai-v1"},
+		{NewID(grp, NewRunRange(150, 150), -1, -1), "grp-v1"},
+	})
+	defer cleanup()
+
+	t.Run("get", func(t *testing.T) {
+		for _, tc := range []struct {
+			path string
+			run  int32
+			want string
+			err  error
+		}{
+			{"MUON/Calib/Pedestals", 50, "ped-v1", nil},
+			{"MUON/Calib/Pedestals", 150, "ped-v2s1", nil},
+			{"MUON/Calib/Pedestals", 250, "ped-v1", nil},
+			{"MUON/Calib/Gains", 50, "gai-v1", nil},
+			{"MUON/Calib/Gains", 150, "", ErrNotFound},
+			{"TPC/Calib/Pedestals", 150, "", ErrNotFound},
+		} {
+			entry, err := db.Get(tc.path, tc.run)
+			switch {
+			case tc.err != nil:
+				if errors.Cause(err) != tc.err {
+					t.Fatalf("%s@%d: invalid error: got=%v, want=%v", tc.path, tc.run, err, tc.err)
+				}
+				continue
+			case err != nil:
+				t.Fatalf("%s@%d: could not get entry: %+v", tc.path, tc.run, err)
+			}
+			if got := payload(entry); got != tc.want {
+				t.Fatalf("%s@%d: invalid payload: got=%q, want=%q", tc.path, tc.run, got, tc.want)
+			}
+		}
+
+		_, err := db.Get("MUON/*", 50)
+		if err == nil {
+			t.Fatalf("expected an error for a wildcard path")
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		for _, tc := range []struct {
+			pattern string
+			want    []string
+		}{
+			{
+				pattern: "*",
+				want: []string{
+					"GRP/GRP/Data/Run150_150_v1_s0.root",
+					"MUON/Calib/Gains/Run0_99_v1_s0.root",
+					"MUON/Calib/Pedestals/Run0_999999999_v1_s0.root",
+					"MUON/Calib/Pedestals/Run100_200_v2_s0.root",
+					"MUON/Calib/Pedestals/Run100_200_v2_s1.root",
+				},
+			},
+			{
+				pattern: "MUON/*/Gains",
+				want:    []string{"MUON/Calib/Gains/Run0_99_v1_s0.root"},
+			},
+			{
+				pattern: "TPC/*",
+			},
+		} {
+			ids, err := db.List(tc.pattern)
+			if err != nil {
+				t.Fatalf("%s: could not list: %+v", tc.pattern, err)
+			}
+			got := idNames(ids)
+			if !equalStrings(got, tc.want) {
+				t.Fatalf("%s: invalid IDs:\ngot= %q\nwant=%q", tc.pattern, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("get-all", func(t *testing.T) {
+		entries, err := db.GetAll("*", 150)
+		if err != nil {
+			t.Fatalf("could not get entries: %+v", err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, payload(entry))
+		}
+		if want := []string{"grp-v1", "ped-v2s1"}; !equalStrings(got, want) {
+			t.Fatalf("invalid entries: got=%q, want=%q", got, want)
+		}
+	})
+
+	t.Run("load", func(t *testing.T) {
+		entry, err := db.Load(NewID(ped, NewRunRange(100, 200), 2, 0))
+		if err != nil {
+			t.Fatalf("could not load entry: %+v", err)
+		}
+		if got, want := payload(entry), "ped-v2"; got != want {
+			t.Fatalf("invalid payload: got=%q, want=%q", got, want)
+		}
+
+		_, err = db.Load(NewID(ped, NewRunRange(100, 200), 2, 5))
+		if errors.Cause(err) != ErrNotFound {
+			t.Fatalf("invalid error: got=%v, want=%v", err, ErrNotFound)
+		}
+	})
+}
+
+// idNames returns the file names, relative to the storage, of ids.
+func idNames(ids []ID) []string {
+	var names []string
+	for _, id := range ids {
+		names = append(names, id.path.path+"/"+FormatFilename(id.runs, id.vers, id.subvers))
+	}
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPrepareID(t *testing.T) {
+	var (
+		ped = NewPath("MUON", "Calib", "Pedestals")
+		ids = []ID{
+			NewID(ped, NewRunRange(0, 99), 1, 0),
+			NewID(ped, NewRunRange(0, 99), 1, 1),
+			NewID(ped, NewRunRange(50, 150), 2, 0),
+			NewID(ped, NewRunRange(200, 300), 5, 3),
+		}
+	)
+
+	for _, tc := range []struct {
+		name  string
+		id    ID
+		empty bool // whether the storage is empty
+		vers  int32
+		sub   int32
+		warn  bool // whether the run range changed w.r.t. the previous version
+		err   bool
+	}{
+		{
+			name:  "empty-storage",
+			id:    NewID(ped, NewRunRange(0, 99), -1, -1),
+			empty: true,
+			vers:  1, sub: 0,
+		},
+		{
+			name: "new-version",
+			id:   NewID(ped, NewRunRange(0, 10), -1, -1),
+			vers: 2, sub: 0,
+			warn: true,
+		},
+		{
+			name: "new-version-overlapping",
+			id:   NewID(ped, NewRunRange(90, 250), -1, -1),
+			vers: 6, sub: 0,
+			warn: true,
+		},
+		{
+			name: "new-subversion",
+			id:   NewID(ped, NewRunRange(0, 99), 1, -1),
+			vers: 1, sub: 2,
+		},
+		{
+			name: "first-subversion",
+			id:   NewID(ped, NewRunRange(0, 99), 4, -1),
+			vers: 4, sub: 0,
+		},
+		{
+			name: "subversion-ignored",
+			id:   NewID(ped, NewRunRange(0, 99), 1, 7),
+			vers: 1, sub: 2,
+		},
+		{
+			name: "grid-transferred",
+			id:   ID{path: ped, runs: NewRunRange(0, 99), vers: 1, subvers: -1, last: "alien://grid"},
+			err:  true,
+		},
+		{
+			name: "grid-first-transfer",
+			id:   ID{path: ped, runs: NewRunRange(500, 600), vers: 1, subvers: -1, last: "alien://grid"},
+			vers: 1, sub: 0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stored := ids
+			if tc.empty {
+				stored = nil
+			}
+			id, warning, err := prepareID(tc.id, stored)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected an error, got v%d_s%d", id.vers, id.subvers)
+			case tc.err:
+				return
+			case err != nil:
+				t.Fatalf("could not prepare ID: %+v", err)
+			}
+			if id.vers != tc.vers || id.subvers != tc.sub {
+				t.Fatalf("invalid version: got=v%d_s%d, want=v%d_s%d", id.vers, id.subvers, tc.vers, tc.sub)
+			}
+			if !id.runs.Equal(tc.id.runs) {
+				t.Fatalf("run range modified: got=%v, want=%v", id.runs, tc.id.runs)
+			}
+			if got := warning != ""; got != tc.warn {
+				t.Fatalf("invalid warning: got=%q, want warning=%v", warning, tc.warn)
+			}
+		})
+	}
+}
+
+func TestLocalPut(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	db, cleanup := newTestLocal(t, nil)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		id   ID
+		want string // name of the stored file, or empty if an error is expected
+	}{
+		{NewID(ped, NewRunRange(0, 99), -1, -1), "Run0_99_v1_s0.root"},
+		{NewID(ped, NewRunRange(0, 99), -1, -1), "Run0_99_v2_s0.root"},
+		{NewID(ped, NewRunRange(100, 199), -1, -1), "Run100_199_v1_s0.root"},
+		{NewID(ped, NewRunRange(50, 150), -1, -1), "Run50_150_v3_s0.root"},
+		{NewID(ped, NewRunRange(0, 99), 2, -1), "Run0_99_v2_s1.root"},
+		{NewID(ped, NewRunRange(0, 99), 2, -1), "Run0_99_v2_s2.root"},
+		{NewID(ped, NewRunRange(0, Infinity), 9, -1), "Run0_999999999_v9_s0.root"},
+		{NewID(ped, NewRunRange(-1, -1), -1, -1), ""},
+		{NewID(ped, NewRunRange(10, 5), -1, -1), ""},
+		{NewID(NewPath("MUON", "*", "Pedestals"), NewRunRange(0, 99), -1, -1), ""},
+		{NewID(NewPath("MUON", "Calib", "Ped estals"), NewRunRange(0, 99), -1, -1), ""},
+	} {
+		entry := testPut{id: tc.id, payload: tc.want}.entry()
+		id, err := db.Put(entry)
+		switch {
+		case tc.want == "" && err == nil:
+			t.Fatalf("%v: expected an error, got %q", tc.id, db.Filename(id))
+		case tc.want == "":
+			continue
+		case err != nil:
+			t.Fatalf("%v: could not store entry: %+v", tc.id, err)
+		}
+
+		if got := FormatFilename(id.runs, id.vers, id.subvers); got != tc.want {
+			t.Fatalf("invalid ID: got=%q, want=%q", got, tc.want)
+		}
+		if got, want := id.LastStorage(), "local"; got != want {
+			t.Fatalf("invalid last storage: got=%q, want=%q", got, want)
+		}
+		if !sameID(entry.Id(), id) {
+			t.Fatalf("entry ID not updated: got=%v, want=%v", entry.Id(), id)
+		}
+
+		loaded, err := db.Load(id)
+		if err != nil {
+			t.Fatalf("could not load stored entry: %+v", err)
+		}
+		if got := payload(loaded); got != tc.want {
+			t.Fatalf("invalid stored payload: got=%q, want=%q", got, tc.want)
+		}
+		if got, want := loaded.MetaData().ObjectClassName(), "TObjString"; got != want {
+			t.Fatalf("invalid stored metadata class: got=%q, want=%q", got, want)
+		}
+	}
+
+	ids, err := db.List("*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(ids), 7; got != want {
+		t.Fatalf("invalid number of stored entries: got=%d, want=%d", got, want)
+	}
+}
+
+func TestLocalPutMetaData(t *testing.T) {
+	db, cleanup := newTestLocal(t, nil)
+	defer cleanup()
+
+	meta := NewMetaData("tester", 0, "v5", "comment")
+	meta.SetObjectClassName("AliMUON2DMap")
+	entry := NewEntry(rbase.NewObjString("payload"), NewID(NewPath("A", "B", "C"), NewRunRange(1, 2), -1, -1), meta, true)
+	orig := entry.MetaData()
+
+	_, err := db.Put(entry)
+	if err != nil {
+		t.Fatalf("could not store entry: %+v", err)
+	}
+	if got, want := entry.MetaData().ObjectClassName(), "TObjString"; got != want {
+		t.Fatalf("invalid entry metadata class: got=%q, want=%q", got, want)
+	}
+	for _, m := range []*MetaData{meta, orig} {
+		if got, want := m.ObjectClassName(), "AliMUON2DMap"; got != want {
+			t.Fatalf("caller metadata modified: class=%q", got)
+		}
+	}
+}
+
+func TestLocalPutWarning(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	db, cleanup := newTestLocal(t, nil)
+	defer cleanup()
+
+	var warnings []string
+	db.SetWarner(func(msg string) { warnings = append(warnings, msg) })
+
+	for _, tc := range []struct {
+		id   ID
+		want int // number of warnings
+	}{
+		{NewID(ped, NewRunRange(0, 99), -1, -1), 0},
+		{NewID(ped, NewRunRange(0, 99), -1, -1), 0},
+		{NewID(ped, NewRunRange(0, 50), -1, -1), 1},
+		{NewID(ped, NewRunRange(0, 50), 3, -1), 1},
+	} {
+		_, err := db.Put(testPut{id: tc.id, payload: "v"}.entry())
+		if err != nil {
+			t.Fatalf("could not store %v: %+v", tc.id, err)
+		}
+		if got := len(warnings); got != tc.want {
+			t.Fatalf("%v: invalid warnings: got=%q, want %d warnings", tc.id.runs, warnings, tc.want)
+		}
+	}
+}
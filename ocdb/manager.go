@@ -0,0 +1,401 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrLocked is returned when trying to modify the configuration of a locked manager.
+	ErrLocked = errors.New("ocdb: manager is locked")
+)
+
+// Manager gives access to the conditions of a run, stored in a default storage
+// and in optional storages specific to some paths.
+// Entries retrieved through a Manager are cached until the run changes.
+//
+// Manager is the equivalent of AliRoot's AliCDBManager.
+// Manager is safe for concurrent use.
+type Manager struct {
+	mu     sync.Mutex
+	def    Storage
+	specs  []specificStorage
+	run    int32
+	cache  map[string]*Entry
+	cached bool   // whether entries are cached
+	gen    uint64 // generation of the cache, incremented when entries are dropped
+
+	locked bool
+	key    uint64
+}
+
+type specificStorage struct {
+	path  Path
+	store Storage
+}
+
+// NewManager creates a new manager with the provided default storage.
+// The default storage may be nil if only specific storages are used.
+// The cache is enabled and the run number is not set.
+func NewManager(def Storage) *Manager {
+	return &Manager{
+		def:    def,
+		run:    -1,
+		cache:  make(map[string]*Entry),
+		cached: true,
+	}
+}
+
+// DefaultStorage returns the default storage of the manager.
+func (mgr *Manager) DefaultStorage() Storage {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.def
+}
+
+// SetDefaultStorage sets the default storage of the manager and clears the cache.
+func (mgr *Manager) SetDefaultStorage(store Storage) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	if mgr.locked {
+		return errors.Wrapf(ErrLocked, "ocdb: could not set default storage")
+	}
+
+	mgr.def = store
+	mgr.clearCache()
+	return nil
+}
+
+// SetSpecificStorage sets the storage to use for paths matching pattern,
+// for example "MUON/Calib/*".
+// Cached entries matching pattern are dropped.
+// Passing a nil storage removes the specific storage for pattern.
+func (mgr *Manager) SetSpecificStorage(pattern string, store Storage) error {
+	p, err := ParsePath(pattern)
+	if err != nil {
+		return err
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	if mgr.locked {
+		return errors.Wrapf(ErrLocked, "ocdb: could not set specific storage for %q", pattern)
+	}
+
+	for k := range mgr.cache {
+		if p.Match(k) {
+			delete(mgr.cache, k)
+		}
+	}
+	mgr.gen++
+
+	for i, spec := range mgr.specs {
+		if spec.path.path != p.path {
+			continue
+		}
+		if store == nil {
+			mgr.specs = append(mgr.specs[:i], mgr.specs[i+1:]...)
+			return nil
+		}
+		mgr.specs[i].store = store
+		return nil
+	}
+
+	if store != nil {
+		mgr.specs = append(mgr.specs, specificStorage{path: p, store: store})
+	}
+	return nil
+}
+
+// Storage returns the storage used for the provided path.
+// The most specific storage whose pattern comprises path is selected,
+// i.e. the one with the fewest wildcard levels, then the first one
+// that was set.
+// The default storage is used when no specific storage matches.
+func (mgr *Manager) Storage(path string) (Storage, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	return mgr.storage(p)
+}
+
+func (mgr *Manager) storage(p Path) (Storage, error) {
+	var (
+		store Storage
+		nwild = 4
+	)
+	for _, spec := range mgr.specs {
+		if !spec.path.Comprises(p) {
+			continue
+		}
+		n := wildcards(spec.path)
+		if n < nwild {
+			store = spec.store
+			nwild = n
+		}
+	}
+	if store != nil {
+		return store, nil
+	}
+
+	if mgr.def == nil {
+		return nil, errors.Errorf("ocdb: no storage for path %q", p.path)
+	}
+	return mgr.def, nil
+}
+
+// Run returns the current run number, or -1 if not set.
+func (mgr *Manager) Run() int32 {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.run
+}
+
+// SetRun sets the current run number.
+// The cache is cleared when the run number changes.
+func (mgr *Manager) SetRun(run int32) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	if mgr.locked {
+		return errors.Wrapf(ErrLocked, "ocdb: could not set run number")
+	}
+	if run < 0 {
+		return errors.Errorf("ocdb: invalid run number %d", run)
+	}
+
+	if run != mgr.run {
+		mgr.clearCache()
+	}
+	mgr.run = run
+	return nil
+}
+
+// SetCache enables or disables the cache of entries.
+// Disabling the cache clears it.
+func (mgr *Manager) SetCache(v bool) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	mgr.cached = v
+	if !v {
+		mgr.clearCache()
+	}
+}
+
+// ClearCache drops all the cached entries.
+func (mgr *Manager) ClearCache() {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.clearCache()
+}
+
+func (mgr *Manager) clearCache() {
+	mgr.cache = make(map[string]*Entry)
+	mgr.gen++
+}
+
+// Get returns the entry stored under path, valid for the current run.
+func (mgr *Manager) Get(path string) (*Entry, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if p.wildcard {
+		return nil, errors.Errorf("ocdb: path %q must not contain wildcards", path)
+	}
+
+	return mgr.get(p)
+}
+
+// get returns the entry stored under p, valid for the current run.
+// The manager lock is not held while the storage is accessed, so the entry
+// is only cached if no entry was dropped from the cache in the meantime.
+func (mgr *Manager) get(p Path) (*Entry, error) {
+	mgr.mu.Lock()
+	if mgr.run < 0 {
+		mgr.mu.Unlock()
+		return nil, errors.Errorf("ocdb: run number not set")
+	}
+
+	if entry, ok := mgr.cache[p.path]; ok {
+		mgr.mu.Unlock()
+		return entry, nil
+	}
+
+	store, err := mgr.storage(p)
+	if err != nil {
+		mgr.mu.Unlock()
+		return nil, err
+	}
+	run, gen := mgr.run, mgr.gen
+	mgr.mu.Unlock()
+
+	entry, err := store.Get(p.path, run)
+	if err != nil {
+		return nil, err
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.cached && mgr.gen == gen {
+		mgr.cache[p.path] = entry
+	}
+	return entry, nil
+}
+
+// GetAll returns the entries stored under any path matching pattern,
+// valid for the current run, sorted by path.
+// Each path is retrieved from the storage selected for it.
+// Paths without any entry valid for the current run are skipped.
+func (mgr *Manager) GetAll(pattern string) ([]*Entry, error) {
+	pat, err := ParsePath(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	mgr.mu.Lock()
+	stores := make([]Storage, 0, len(mgr.specs)+1)
+	if mgr.def != nil {
+		stores = append(stores, mgr.def)
+	}
+	for _, spec := range mgr.specs {
+		stores = append(stores, spec.store)
+	}
+	mgr.mu.Unlock()
+
+	paths := make(map[string]Path)
+	for _, store := range stores {
+		ids, err := store.List(pat.path)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			paths[id.path.path] = id.path
+		}
+	}
+
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var entries []*Entry
+	for _, k := range keys {
+		entry, err := mgr.get(paths[k])
+		if err != nil {
+			if errors.Cause(err) == ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Put stores entry in the storage selected for its path.
+// Put fails if that storage is read-only.
+// The cached entry for that path, if any, is dropped.
+func (mgr *Manager) Put(entry *Entry) (ID, error) {
+	id := entry.id
+
+	mgr.mu.Lock()
+	store, err := mgr.storage(id.path)
+	mgr.mu.Unlock()
+	if err != nil {
+		return id, err
+	}
+
+	w, ok := store.(interface {
+		Put(entry *Entry) (ID, error)
+	})
+	if !ok {
+		return id, errors.Errorf("ocdb: storage for path %q is read-only", id.path.path)
+	}
+
+	id, err = w.Put(entry)
+	if err != nil {
+		return id, err
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	delete(mgr.cache, id.path.path)
+	mgr.gen++
+
+	return id, nil
+}
+
+// Lock forbids any further change of storages or run number,
+// until Unlock is called with the returned key.
+func (mgr *Manager) Lock() (uint64, error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	if mgr.locked {
+		return 0, errors.Wrapf(ErrLocked, "ocdb: manager already locked")
+	}
+
+	var buf [8]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		return 0, errors.Wrapf(err, "ocdb: could not generate lock key")
+	}
+
+	mgr.locked = true
+	mgr.key = binary.LittleEndian.Uint64(buf[:])
+	return mgr.key, nil
+}
+
+// Unlock unlocks the manager, provided key is the one returned by Lock.
+func (mgr *Manager) Unlock(key uint64) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	if !mgr.locked {
+		return nil
+	}
+	if key != mgr.key {
+		return errors.Errorf("ocdb: invalid key to unlock manager")
+	}
+
+	mgr.locked = false
+	mgr.key = 0
+	return nil
+}
+
+// IsLocked returns whether the manager is locked.
+func (mgr *Manager) IsLocked() bool {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.locked
+}
+
+// wildcards returns the number of wildcard levels of p.
+func wildcards(p Path) int {
+	n := 0
+	for _, lvl := range []string{p.lvl0, p.lvl1, p.lvl2} {
+		if lvl == "*" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,274 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestManagerPutDropsCache(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	db, cleanup := newTestLocal(t, []testPut{
+		{NewID(ped, NewRunRange(0, Infinity), -1, -1), "v1"},
+	})
+	defer cleanup()
+
+	mgr := NewManager(db)
+	if err := mgr.SetRun(42); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := mgr.Get(ped.path)
+	if err != nil {
+		t.Fatalf("could not get entry: %+v", err)
+	}
+	if got, want := payload(entry), "v1"; got != want {
+		t.Fatalf("invalid payload: got=%q, want=%q", got, want)
+	}
+
+	_, err = mgr.Put(testPut{NewID(ped, NewRunRange(0, 100), -1, -1), "v2"}.entry())
+	if err != nil {
+		t.Fatalf("could not put entry: %+v", err)
+	}
+
+	entry, err = mgr.Get(ped.path)
+	if err != nil {
+		t.Fatalf("could not get entry: %+v", err)
+	}
+	if got, want := payload(entry), "v2"; got != want {
+		t.Fatalf("stale cached entry: got=%q, want=%q", got, want)
+	}
+}
+
+// blockingStorage is a storage whose Get blocks until released.
+type blockingStorage struct {
+	Storage
+	get     chan struct{} // signaled when Get is called
+	release chan struct{} // closed to let Get return
+}
+
+func (s *blockingStorage) Get(path string, run int32) (*Entry, error) {
+	s.get <- struct{}{}
+	<-s.release
+	return s.Storage.Get(path, run)
+}
+
+func TestManagerGetUnlocked(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	db, cleanup := newTestLocal(t, []testPut{
+		{NewID(ped, NewRunRange(0, Infinity), -1, -1), "v1"},
+	})
+	defer cleanup()
+
+	store := &blockingStorage{
+		Storage: db,
+		get:     make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	mgr := NewManager(store)
+	if err := mgr.SetRun(42); err != nil {
+		t.Fatal(err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := mgr.Get(ped.path)
+		errc <- err
+	}()
+	<-store.get
+
+	done := make(chan struct{})
+	go func() {
+		mgr.ClearCache()
+		mgr.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("manager locked while accessing storage")
+	}
+
+	close(store.release)
+	if err := <-errc; err != nil {
+		t.Fatalf("could not get entry: %+v", err)
+	}
+
+	// the cache was cleared while the entry was retrieved: it must not be cached.
+	mgr.mu.Lock()
+	_, cached := mgr.cache[ped.path]
+	mgr.mu.Unlock()
+	if cached {
+		t.Fatalf("entry cached after the cache was cleared")
+	}
+
+	go func() { <-store.get }()
+	_, err := mgr.Get(ped.path)
+	if err != nil {
+		t.Fatalf("could not get entry: %+v", err)
+	}
+	mgr.mu.Lock()
+	_, cached = mgr.cache[ped.path]
+	mgr.mu.Unlock()
+	if !cached {
+		t.Fatalf("entry not cached")
+	}
+}
+
+func TestManagerSetRunClearsCache(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	db, cleanup := newTestLocal(t, []testPut{
+		{NewID(ped, NewRunRange(0, Infinity), -1, -1), "v1"},
+	})
+	defer cleanup()
+
+	mgr := NewManager(db)
+	if _, err := mgr.Get(ped.path); err == nil {
+		t.Fatalf("expected an error without run number")
+	}
+	if err := mgr.SetRun(-2); err == nil {
+		t.Fatalf("expected an error for an invalid run number")
+	}
+
+	get := func(run int32, want string) {
+		t.Helper()
+		if err := mgr.SetRun(run); err != nil {
+			t.Fatal(err)
+		}
+		entry, err := mgr.Get(ped.path)
+		if err != nil {
+			t.Fatalf("run %d: could not get entry: %+v", run, err)
+		}
+		if got := payload(entry); got != want {
+			t.Fatalf("run %d: invalid payload: got=%q, want=%q", run, got, want)
+		}
+	}
+
+	get(42, "v1")
+
+	// stored behind the back of the manager: not seen until the run changes.
+	_, err := db.Put(testPut{NewID(ped, NewRunRange(0, 100), -1, -1), "v2"}.entry())
+	if err != nil {
+		t.Fatalf("could not put entry: %+v", err)
+	}
+	get(42, "v1")
+	get(43, "v2")
+	get(200, "v1")
+}
+
+func TestManagerStorage(t *testing.T) {
+	type namedStorage struct {
+		Storage
+		name string
+	}
+	var (
+		def    = &namedStorage{name: "default"}
+		muon   = &namedStorage{name: "MUON/*"}
+		calib  = &namedStorage{name: "MUON/Calib/*"}
+		ped    = &namedStorage{name: "MUON/Calib/Pedestals"}
+		calibs = &namedStorage{name: "*/Calib/*"}
+	)
+
+	mgr := NewManager(def)
+	for _, spec := range []*namedStorage{muon, calibs, calib, ped} {
+		if err := mgr.SetSpecificStorage(spec.name, spec); err != nil {
+			t.Fatalf("could not set specific storage %q: %+v", spec.name, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		path string
+		want *namedStorage
+	}{
+		{"MUON/Calib/Pedestals", ped},
+		{"MUON/Calib/Gains", calib},
+		{"MUON/Align/Data", muon},
+		{"TPC/Calib/Gains", calibs},
+		{"TPC/Align/Data", def},
+	} {
+		store, err := mgr.Storage(tc.path)
+		if err != nil {
+			t.Fatalf("%s: could not select storage: %+v", tc.path, err)
+		}
+		if got := store.(*namedStorage); got != tc.want {
+			t.Fatalf("%s: invalid storage: got=%q, want=%q", tc.path, got.name, tc.want.name)
+		}
+	}
+
+	// equally specific patterns: the first one set wins.
+	gains := &namedStorage{name: "MUON/*/Gains"}
+	if err := mgr.SetSpecificStorage(gains.name, gains); err != nil {
+		t.Fatal(err)
+	}
+	if store, _ := mgr.Storage("MUON/Calib/Gains"); store.(*namedStorage) != calib {
+		t.Fatalf("invalid storage: got=%q, want=%q", store.(*namedStorage).name, calib.name)
+	}
+
+	// removing a specific storage falls back to less specific ones.
+	if err := mgr.SetSpecificStorage("MUON/Calib/*", nil); err != nil {
+		t.Fatal(err)
+	}
+	if store, _ := mgr.Storage("MUON/Calib/Gains"); store.(*namedStorage) != gains {
+		t.Fatalf("invalid storage: got=%q, want=%q", store.(*namedStorage).name, gains.name)
+	}
+
+	mgr = NewManager(nil)
+	if _, err := mgr.Storage("TPC/Align/Data"); err == nil {
+		t.Fatalf("expected an error without default storage")
+	}
+}
+
+func TestManagerLock(t *testing.T) {
+	mgr := NewManager(nil)
+	if err := mgr.SetRun(1); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := mgr.Lock()
+	if err != nil {
+		t.Fatalf("could not lock manager: %+v", err)
+	}
+	if !mgr.IsLocked() {
+		t.Fatalf("manager not locked")
+	}
+	if _, err := mgr.Lock(); errors.Cause(err) != ErrLocked {
+		t.Fatalf("invalid error locking a locked manager: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		f    func() error
+	}{
+		{"SetRun", func() error { return mgr.SetRun(2) }},
+		{"SetDefaultStorage", func() error { return mgr.SetDefaultStorage(nil) }},
+		{"SetSpecificStorage", func() error { return mgr.SetSpecificStorage("MUON/*", nil) }},
+	} {
+		if err := tc.f(); errors.Cause(err) != ErrLocked {
+			t.Fatalf("%s: invalid error on a locked manager: %v", tc.name, err)
+		}
+	}
+	if got, want := mgr.Run(), int32(1); got != want {
+		t.Fatalf("run modified by a locked manager: got=%d, want=%d", got, want)
+	}
+
+	if err := mgr.Unlock(key + 1); err == nil {
+		t.Fatalf("expected an error unlocking with an invalid key")
+	}
+	if !mgr.IsLocked() {
+		t.Fatalf("manager unlocked with an invalid key")
+	}
+	if err := mgr.Unlock(key); err != nil {
+		t.Fatalf("could not unlock manager: %+v", err)
+	}
+	if mgr.IsLocked() {
+		t.Fatalf("manager still locked")
+	}
+	if err := mgr.SetRun(2); err != nil {
+		t.Fatalf("could not set run of an unlocked manager: %+v", err)
+	}
+}
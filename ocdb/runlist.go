@@ -0,0 +1,63 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReadRunList reads the run numbers listed in the named file, separated by
+// white space or commas. Lines starting with '#' are ignored.
+// Runs are returned sorted, without duplicates, in a non-nil slice.
+func ReadRunList(fname string) ([]int32, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: could not open run list")
+	}
+	defer f.Close()
+
+	runs, err := readRunList(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: %s", fname)
+	}
+	return runs, nil
+}
+
+func readRunList(r io.Reader) ([]int32, error) {
+	var (
+		runs = []int32{}
+		seen = make(map[int32]bool)
+	)
+	sc := bufio.NewScanner(r)
+	for i := 1; sc.Scan(); i++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, tok := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			v, err := strconv.ParseInt(tok, 10, 32)
+			if err != nil || v < 0 {
+				return nil, errors.Errorf("line %d: invalid run number %q", i, tok)
+			}
+			if !seen[int32(v)] {
+				seen[int32(v)] = true
+				runs = append(runs, int32(v))
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrapf(err, "could not read run list")
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i] < runs[j] })
+	return runs, nil
+}
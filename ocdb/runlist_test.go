@@ -0,0 +1,82 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRunList(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		txt  string
+		want []int32
+		err  bool
+	}{
+		{name: "empty", txt: "", want: []int32{}},
+		{name: "comments", txt: "# runs\n\n  # more\n", want: []int32{}},
+		{name: "lines", txt: "297624\n297590\n", want: []int32{297590, 297624}},
+		{name: "separators", txt: "3, 1\t2 ,5\n4", want: []int32{1, 2, 3, 4, 5}},
+		{name: "duplicates", txt: "1 2\n2,1\n", want: []int32{1, 2}},
+		{name: "negative", txt: "1\n-2\n", err: true},
+		{name: "invalid", txt: "1\nrun2\n", err: true},
+		{name: "range", txt: "1:10\n", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			runs, err := readRunList(strings.NewReader(tc.txt))
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected an error, got %v", runs)
+			case tc.err:
+				return
+			case err != nil:
+				t.Fatalf("could not read run list: %+v", err)
+			}
+			if !reflect.DeepEqual(runs, tc.want) {
+				t.Fatalf("invalid runs: got=%v, want=%v", runs, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadRunListFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocdb-runlist-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fname := filepath.Join(dir, "runs.txt")
+	err = ioutil.WriteFile(fname, []byte("# LHC18o\n297624, 297590\n297624\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs, err := ReadRunList(fname)
+	if err != nil {
+		t.Fatalf("could not read run list: %+v", err)
+	}
+	if want := []int32{297590, 297624}; !reflect.DeepEqual(runs, want) {
+		t.Fatalf("invalid runs: got=%v, want=%v", runs, want)
+	}
+
+	err = ioutil.WriteFile(fname, []byte("297624\nrun2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadRunList(fname)
+	if err == nil || !strings.Contains(err.Error(), fname+": line 2") {
+		t.Fatalf("invalid error for an invalid run list: %v", err)
+	}
+
+	_, err = ReadRunList(filepath.Join(dir, "missing.txt"))
+	if err == nil {
+		t.Fatalf("expected an error for a missing run list")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"github.com/pkg/errors"
+	"go-hep.org/x/hep/groot"
+	"go-hep.org/x/hep/groot/rcont"
+)
+
+const (
+	snapshotEntries = "CDBentriesMap" // key of the map of entries in single-keys snapshots
+)
+
+// Snapshot is a read-only OCDB storage backed by an AliRoot snapshot file,
+// as created by AliCDBManager::DumpToSnapshotFile.
+//
+// Both snapshot layouts are supported:
+//   - a single "CDBentriesMap" TMap of (path, AliCDBEntry) pairs,
+//   - one AliCDBEntry key per entry, named after its path with '/' replaced by '*'.
+//
+// All the entries are loaded in memory when the snapshot is opened.
+type Snapshot struct {
+	entries []*Entry
+	ids     []ID
+}
+
+// OpenSnapshot opens the named snapshot file and loads all its entries.
+func OpenSnapshot(fname string) (*Snapshot, error) {
+	f, err := groot.Open(fname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ocdb: could not open snapshot file %q", fname)
+	}
+	defer f.Close()
+
+	var entries []*Entry
+	switch o, err := f.Get(snapshotEntries); {
+	case err == nil:
+		m, ok := o.(*rcont.Map)
+		if !ok {
+			return nil, errors.Errorf("ocdb: key %q from snapshot %q is a %T, not a TMap", snapshotEntries, fname, o)
+		}
+		for _, v := range m.Table() {
+			entry, ok := v.(*Entry)
+			if !ok {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+
+	default:
+		cycles := make(map[string]int)
+		index := make(map[string]int)
+		for _, k := range f.Keys() {
+			if k.ClassName() != "AliCDBEntry" {
+				continue
+			}
+			if c, dup := cycles[k.Name()]; dup && c > k.Cycle() {
+				continue
+			}
+			o, err := k.Object()
+			if err != nil {
+				return nil, errors.Wrapf(err, "ocdb: could not read key %q from snapshot %q", k.Name(), fname)
+			}
+			entry, ok := o.(*Entry)
+			if !ok {
+				continue
+			}
+			cycles[k.Name()] = k.Cycle()
+			if i, dup := index[k.Name()]; dup {
+				entries[i] = entry
+				continue
+			}
+			index[k.Name()] = len(entries)
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.Errorf("ocdb: no entry in snapshot %q", fname)
+	}
+
+	snap := &Snapshot{
+		entries: entries,
+		ids:     make([]ID, len(entries)),
+	}
+	for i, entry := range entries {
+		snap.ids[i] = entry.id
+	}
+	sortIDs(snap.ids)
+
+	return snap, nil
+}
+
+// Get returns the entry stored under path, valid for the provided run.
+func (snap *Snapshot) Get(path string, run int32) (*Entry, error) {
+	id, err := snap.GetID(path, run)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Load(id)
+}
+
+// GetAll returns the entries stored under any path matching pattern,
+// valid for the provided run.
+// Paths without any entry valid for that run are skipped.
+func (snap *Snapshot) GetAll(pattern string, run int32) ([]*Entry, error) {
+	ids, err := snap.List(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, ids := range splitIDs(ids) {
+		query := NewID(ids[0].path, NewRunRange(run, run), -1, -1)
+		id, err := ResolveID(ids, query)
+		if err != nil {
+			if errors.Cause(err) == ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		entry, err := snap.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetID returns the ID of the entry stored under path, valid for the provided run.
+func (snap *Snapshot) GetID(path string, run int32) (ID, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return ID{}, err
+	}
+	if p.wildcard {
+		return ID{}, errors.Errorf("ocdb: path %q must not contain wildcards", path)
+	}
+
+	return ResolveID(snap.ids, NewID(p, NewRunRange(run, run), -1, -1))
+}
+
+// List returns the IDs of all the entries stored under any path matching pattern.
+// IDs are sorted by path, first run, version and subversion.
+func (snap *Snapshot) List(pattern string) ([]ID, error) {
+	p, err := ParsePath(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []ID
+	for _, id := range snap.ids {
+		if !p.Comprises(id.path) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Load returns the entry exactly identified by id.
+func (snap *Snapshot) Load(id ID) (*Entry, error) {
+	for _, entry := range snap.entries {
+		v := entry.id
+		if v.path.path == id.path.path && v.runs.Equal(id.runs) &&
+			v.vers == id.vers && v.subvers == id.subvers {
+			return entry, nil
+		}
+	}
+	return nil, errors.Wrapf(ErrNotFound, "ocdb: no entry %v in snapshot", id)
+}
+
+var (
+	_ Storage = (*Snapshot)(nil)
+)
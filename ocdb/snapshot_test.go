@@ -0,0 +1,177 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"go-hep.org/x/hep/groot"
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/rcont"
+	"go-hep.org/x/hep/groot/riofs"
+)
+
+// writeSnapshot writes entries into the named snapshot file, either in a
+// single map of entries or with one key per entry.
+// The file is not compressed: groot may write ZLIB buffers it can not read
+// back when they barely compress.
+func writeSnapshot(fname string, entries []*Entry, single bool) error {
+	f, err := groot.Create(fname, riofs.WithoutCompression())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch {
+	case single:
+		m := rcont.NewMap()
+		m.SetName(snapshotEntries)
+		for _, entry := range entries {
+			m.Table()[rbase.NewObjString(entry.id.path.path)] = entry
+		}
+		err = f.Put(snapshotEntries, m)
+	default:
+		for _, entry := range entries {
+			err = f.Put(strings.Replace(entry.id.path.path, "/", "*", -1), entry)
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocdb-snapshot-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		ped   = NewPath("MUON", "Calib", "Pedestals")
+		gains = NewPath("MUON", "Calib", "Gains")
+		grp   = NewPath("GRP", "GRP", "Data")
+	)
+	puts := []testPut{
+		{NewID(ped, NewRunRange(0, 99), 2, 1), "ped"},
+		{NewID(gains, NewRunRange(50, Infinity), 1, 0), "gains"},
+		{NewID(grp, NewRunRange(42, 42), 3, 0), "grp"},
+	}
+	var entries []*Entry
+	for _, p := range puts {
+		entries = append(entries, p.entry())
+	}
+
+	for _, tc := range []struct {
+		name   string
+		single bool
+	}{
+		{"single-key", true},
+		{"keys", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fname := filepath.Join(dir, tc.name+".root")
+			err := writeSnapshot(fname, entries, tc.single)
+			if err != nil {
+				t.Fatalf("could not write snapshot: %+v", err)
+			}
+
+			snap, err := OpenSnapshot(fname)
+			if err != nil {
+				t.Fatalf("could not open snapshot: %+v", err)
+			}
+
+			ids, err := snap.List("*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{
+				"GRP/GRP/Data/Run42_42_v3_s0.root",
+				"MUON/Calib/Gains/Run50_999999999_v1_s0.root",
+				"MUON/Calib/Pedestals/Run0_99_v2_s1.root",
+			}
+			if got := idNames(ids); !equalStrings(got, want) {
+				t.Fatalf("invalid IDs:\ngot= %q\nwant=%q", got, want)
+			}
+
+			ids, err = snap.List("MUON/*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := idNames(ids); !equalStrings(got, want[1:]) {
+				t.Fatalf("invalid MUON IDs:\ngot= %q\nwant=%q", got, want[1:])
+			}
+
+			for _, q := range []struct {
+				path string
+				run  int32
+				want string // payload, or empty if no entry applies
+			}{
+				{"MUON/Calib/Pedestals", 0, "ped"},
+				{"MUON/Calib/Pedestals", 99, "ped"},
+				{"MUON/Calib/Pedestals", 100, ""},
+				{"MUON/Calib/Gains", 49, ""},
+				{"MUON/Calib/Gains", 297624, "gains"},
+				{"GRP/GRP/Data", 42, "grp"},
+				{"GRP/GRP/Data", 43, ""},
+				{"TPC/Calib/Gains", 42, ""},
+			} {
+				entry, err := snap.Get(q.path, q.run)
+				switch {
+				case q.want == "":
+					if errors.Cause(err) != ErrNotFound {
+						t.Fatalf("%s, run %d: expected ErrNotFound, got %v", q.path, q.run, err)
+					}
+					continue
+				case err != nil:
+					t.Fatalf("%s, run %d: could not get entry: %+v", q.path, q.run, err)
+				}
+				if got := payload(entry); got != q.want {
+					t.Fatalf("%s, run %d: invalid payload: got=%q, want=%q", q.path, q.run, got, q.want)
+				}
+			}
+
+			all, err := snap.GetAll("MUON/Calib/*", 60)
+			if err != nil {
+				t.Fatalf("could not get entries: %+v", err)
+			}
+			var got []string
+			for _, entry := range all {
+				got = append(got, payload(entry))
+			}
+			if want := []string{"gains", "ped"}; !equalStrings(got, want) {
+				t.Fatalf("invalid entries: got=%q, want=%q", got, want)
+			}
+
+			if _, err := snap.Get("MUON/*/Pedestals", 0); err == nil {
+				t.Fatalf("expected an error for a wildcard path")
+			}
+		})
+	}
+
+	fname := filepath.Join(dir, "empty.root")
+	f, err := groot.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Put("payload", rbase.NewObjString("payload")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenSnapshot(fname); err == nil {
+		t.Fatalf("expected an error for a snapshot without entries")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotFound is returned (possibly wrapped) when no entry matches a query.
+	ErrNotFound = errors.New("ocdb: no entry found")
+)
+
+// Storage is the interface implemented by OCDB storages.
+type Storage interface {
+	// Get returns the entry stored under path, valid for the provided run.
+	Get(path string, run int32) (*Entry, error)
+
+	// GetAll returns the entries stored under any path matching pattern,
+	// valid for the provided run.
+	GetAll(pattern string, run int32) ([]*Entry, error)
+
+	// GetID returns the ID of the entry stored under path, valid for the provided run.
+	GetID(path string, run int32) (ID, error)
+
+	// List returns the IDs of all the entries stored under any path matching pattern.
+	List(pattern string) ([]ID, error)
+
+	// Load returns the entry exactly identified by id.
+	Load(id ID) (*Entry, error)
+}
+
+// ResolveID selects among ids the one matching the query, following the rules of AliCDBLocal:
+//   - only IDs with the same path and whose run range comprises the query run range are considered,
+//   - if the query has no version, the highest version and then highest subversion is selected,
+//   - if the query has a version but no subversion, the highest subversion of that version is selected,
+//   - otherwise, the exact version and subversion are selected.
+//
+// ResolveID returns an error if several IDs are equally eligible, and ErrNotFound if none is.
+func ResolveID(ids []ID, query ID) (ID, error) {
+	var (
+		best  ID
+		found = false
+		dup   = false
+	)
+
+	for _, id := range ids {
+		if id.path.path != query.path.path {
+			continue
+		}
+		if !id.runs.Comprises(query.runs) {
+			continue
+		}
+		if query.vers >= 0 && id.vers != query.vers {
+			continue
+		}
+		if query.vers >= 0 && query.subvers >= 0 && id.subvers != query.subvers {
+			continue
+		}
+		switch {
+		case !found, id.vers > best.vers, id.vers == best.vers && id.subvers > best.subvers:
+			best = id
+			found = true
+			dup = false
+		case id.vers == best.vers && id.subvers == best.subvers:
+			dup = true
+		}
+	}
+
+	if !found {
+		return best, errors.Wrapf(ErrNotFound, "ocdb: no entry for path %q valid for %v", query.path.path, query.runs)
+	}
+	if dup {
+		return best, errors.Errorf(
+			"ocdb: more than one object valid for path %q, %v, version %d_%d",
+			query.path.path, query.runs, best.vers, best.subvers,
+		)
+	}
+	return best, nil
+}
+
+// Superseded returns the IDs among ids that ResolveID never selects:
+// for every run of their run range, another ID with the same path and a higher
+// version, or the same version and a higher subversion, is valid.
+// IDs are returned sorted by path, first run, version and subversion.
+func Superseded(ids []ID) []ID {
+	ids = append([]ID(nil), ids...)
+	sortIDs(ids)
+
+	var out []ID
+	for _, grp := range splitIDs(ids) {
+		for _, id := range grp {
+			var higher []RunRange
+			for _, o := range grp {
+				if o.runs.First > id.runs.Last {
+					break // grp is sorted by first run.
+				}
+				if !o.runs.Overlaps(id.runs) {
+					continue
+				}
+				if o.vers > id.vers || (o.vers == id.vers && o.subvers > id.subvers) {
+					higher = append(higher, o.runs)
+				}
+			}
+			if covers(higher, id.runs) {
+				out = append(out, id)
+			}
+		}
+	}
+	return out
+}
+
+// covers returns whether the union of rs comprises runs.
+func covers(rs []RunRange, runs RunRange) bool {
+	sort.Slice(rs, func(i, j int) bool { return rs[i].First < rs[j].First })
+	next := int64(runs.First) // first run not yet covered
+	for _, r := range rs {
+		if int64(r.First) > next {
+			break
+		}
+		if v := int64(r.Last) + 1; v > next {
+			next = v
+		}
+	}
+	return next > int64(runs.Last)
+}
+
+// sortIDs sorts ids by path, first run, version and subversion.
+func sortIDs(ids []ID) {
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i], ids[j]
+		switch {
+		case a.path.path != b.path.path:
+			return a.path.path < b.path.path
+		case a.runs.First != b.runs.First:
+			return a.runs.First < b.runs.First
+		case a.vers != b.vers:
+			return a.vers < b.vers
+		default:
+			return a.subvers < b.subvers
+		}
+	})
+}
@@ -0,0 +1,214 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestResolveID(t *testing.T) {
+	var (
+		ped = NewPath("MUON", "Calib", "Pedestals")
+		gai = NewPath("MUON", "Calib", "Gains")
+		ids = []ID{
+			NewID(ped, NewRunRange(0, Infinity), 1, 0),
+			NewID(ped, NewRunRange(100, 200), 2, 0),
+			NewID(ped, NewRunRange(100, 200), 2, 1),
+			NewID(ped, NewRunRange(150, 300), 3, 0),
+			NewID(ped, NewRunRange(400, 400), 1, 1),
+			NewID(gai, NewRunRange(0, Infinity), 7, 0),
+		}
+	)
+
+	for _, tc := range []struct {
+		name  string
+		query ID
+		want  ID
+		err   error // expected error cause, or nil
+	}{
+		{
+			name:  "default-version",
+			query: NewID(ped, NewRunRange(50, 50), -1, -1),
+			want:  ids[0],
+		},
+		{
+			name:  "highest-subversion",
+			query: NewID(ped, NewRunRange(120, 120), -1, -1),
+			want:  ids[2],
+		},
+		{
+			name:  "highest-version",
+			query: NewID(ped, NewRunRange(160, 160), -1, -1),
+			want:  ids[3],
+		},
+		{
+			name:  "range-comprised",
+			query: NewID(ped, NewRunRange(100, 140), -1, -1),
+			want:  ids[2],
+		},
+		{
+			name:  "range-not-comprised",
+			query: NewID(ped, NewRunRange(100, 250), -1, -1),
+			want:  ids[0],
+		},
+		{
+			name:  "version",
+			query: NewID(ped, NewRunRange(160, 160), 2, -1),
+			want:  ids[2],
+		},
+		{
+			name:  "version-subversion",
+			query: NewID(ped, NewRunRange(160, 160), 2, 0),
+			want:  ids[1],
+		},
+		{
+			name:  "subversion-without-version",
+			query: NewID(ped, NewRunRange(400, 400), -1, 0),
+			want:  ids[4],
+		},
+		{
+			name:  "other-path",
+			query: NewID(gai, NewRunRange(160, 160), -1, -1),
+			want:  ids[5],
+		},
+		{
+			name:  "missing-version",
+			query: NewID(ped, NewRunRange(50, 50), 2, -1),
+			err:   ErrNotFound,
+		},
+		{
+			name:  "missing-path",
+			query: NewID(NewPath("TPC", "Calib", "Pedestals"), NewRunRange(50, 50), -1, -1),
+			err:   ErrNotFound,
+		},
+		{
+			name:  "no-run",
+			query: NewID(ped, NewRunRange(-1, -1), -1, -1),
+			err:   ErrNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := ResolveID(ids, tc.query)
+			switch {
+			case tc.err != nil:
+				if errors.Cause(err) != tc.err {
+					t.Fatalf("invalid error: got=%v, want=%v", err, tc.err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("could not resolve ID: %+v", err)
+			}
+			if !sameID(id, tc.want) {
+				t.Fatalf("invalid ID:\ngot= %v\nwant=%v", id, tc.want)
+			}
+		})
+	}
+
+	t.Run("ambiguous", func(t *testing.T) {
+		dups := append(ids[:len(ids):len(ids)], NewID(ped, NewRunRange(160, 170), 3, 0))
+		_, err := ResolveID(dups, NewID(ped, NewRunRange(160, 160), -1, -1))
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+		if errors.Cause(err) == ErrNotFound {
+			t.Fatalf("invalid error: %v", err)
+		}
+	})
+}
+
+// sameID returns whether a and b have the same path, run range, version and subversion.
+func sameID(a, b ID) bool {
+	return a.path.path == b.path.path &&
+		a.runs.Equal(b.runs) &&
+		a.vers == b.vers &&
+		a.subvers == b.subvers
+}
+
+func TestSuperseded(t *testing.T) {
+	var (
+		ped = NewPath("MUON", "Calib", "Pedestals")
+		gai = NewPath("MUON", "Calib", "Gains")
+	)
+
+	for _, tc := range []struct {
+		name string
+		ids  []ID
+		want []ID
+	}{
+		{
+			name: "version",
+			ids: []ID{
+				NewID(ped, NewRunRange(0, 99), 1, 0),
+				NewID(ped, NewRunRange(0, 99), 2, 0),
+			},
+			want: []ID{NewID(ped, NewRunRange(0, 99), 1, 0)},
+		},
+		{
+			name: "subversion",
+			ids: []ID{
+				NewID(ped, NewRunRange(0, 99), 2, 1),
+				NewID(ped, NewRunRange(0, 99), 2, 0),
+				NewID(ped, NewRunRange(0, 99), 1, 3),
+			},
+			want: []ID{
+				NewID(ped, NewRunRange(0, 99), 1, 3),
+				NewID(ped, NewRunRange(0, 99), 2, 0),
+			},
+		},
+		{
+			name: "non-overlapping",
+			ids: []ID{
+				NewID(ped, NewRunRange(0, 99), 1, 0),
+				NewID(ped, NewRunRange(100, 199), 2, 0),
+			},
+		},
+		{
+			name: "partially-covered",
+			ids: []ID{
+				NewID(ped, NewRunRange(0, 99), 1, 0),
+				NewID(ped, NewRunRange(0, 49), 2, 0),
+				NewID(ped, NewRunRange(51, 99), 3, 0),
+			},
+		},
+		{
+			name: "covered-by-several",
+			ids: []ID{
+				NewID(ped, NewRunRange(51, 99), 3, 0),
+				NewID(ped, NewRunRange(0, 99), 1, 0),
+				NewID(ped, NewRunRange(0, 50), 2, 0),
+			},
+			want: []ID{NewID(ped, NewRunRange(0, 99), 1, 0)},
+		},
+		{
+			name: "lower-version-does-not-supersede",
+			ids: []ID{
+				NewID(ped, NewRunRange(0, 99), 2, 0),
+				NewID(ped, NewRunRange(0, Infinity), 1, 0),
+			},
+		},
+		{
+			name: "other-path",
+			ids: []ID{
+				NewID(ped, NewRunRange(0, 99), 1, 0),
+				NewID(gai, NewRunRange(0, 99), 2, 0),
+				NewID(gai, NewRunRange(0, Infinity), 3, 0),
+			},
+			want: []ID{NewID(gai, NewRunRange(0, 99), 2, 0)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			orig := append([]ID(nil), tc.ids...)
+			got := Superseded(tc.ids)
+			if !equalStrings(idNames(got), idNames(tc.want)) {
+				t.Fatalf("invalid superseded IDs:\ngot= %q\nwant=%q", idNames(got), idNames(tc.want))
+			}
+			if !equalStrings(idNames(tc.ids), idNames(orig)) {
+				t.Fatalf("input IDs modified")
+			}
+		})
+	}
+}
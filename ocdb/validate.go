@@ -0,0 +1,161 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Severity is the severity of a validation finding.
+type Severity int
+
+// Severities start at 1, so that the zero value is not a valid severity.
+const (
+	SeverityWarning Severity = iota + 1 // suspicious, but usable, entry
+	SeverityError                       // inconsistent or unusable entry
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Validation rules.
+const (
+	RuleRead     = "read"     // the file could not be read as an OCDB entry
+	RuleFilename = "filename" // the file name is not of the Run<first>_<last>_v<version>_s<subversion>.root form
+	RulePath     = "path"     // the path of the entry is invalid or disagrees with the file location
+	RuleRunRange = "runrange" // the run range of the entry is invalid or disagrees with the file name
+	RuleVersion  = "version"  // the version of the entry disagrees with the file name
+	RuleObject   = "object"   // the entry holds no object
+	RuleClass    = "class"    // the class recorded in the metadata disagrees with the object class
+	RuleMetaData = "metadata" // the entry has no metadata
+)
+
+// Finding is a problem found while validating an OCDB file.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Rule     string   `json:"rule"`
+	Msg      string   `json:"msg"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %v: %s: %s", f.File, f.Severity, f.Rule, f.Msg)
+}
+
+// Validate reads the OCDB entry stored in the named file and checks it is
+// consistent with the location of the file.
+func Validate(fname string) []Finding {
+	entry, err := ReadEntry(fname)
+	if err != nil {
+		return []Finding{{Severity: SeverityError, File: fname, Rule: RuleRead, Msg: err.Error()}}
+	}
+	return ValidateEntry(fname, entry)
+}
+
+// ValidateEntry checks the entry read from the named file:
+//   - the file name encodes the run range, version and subversion of the entry ID,
+//   - the last three directories of the file name are the levels of the entry path,
+//   - the entry path and run range are valid,
+//   - the entry holds an object whose class is the one recorded in its metadata.
+func ValidateEntry(fname string, entry *Entry) []Finding {
+	var fs []Finding
+	add := func(sev Severity, rule, format string, args ...interface{}) {
+		fs = append(fs, Finding{Severity: sev, File: fname, Rule: rule, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	id := entry.id
+
+	p, err := ParsePath(id.path.path)
+	switch {
+	case err != nil:
+		add(SeverityError, RulePath, "invalid path %q", id.path.path)
+	case p.wildcard:
+		add(SeverityError, RulePath, "path %q contains wildcards", id.path.path)
+	case p.lvl0 != id.path.lvl0 || p.lvl1 != id.path.lvl1 || p.lvl2 != id.path.lvl2:
+		add(SeverityError, RulePath, "path %q disagrees with its levels [%q, %q, %q]",
+			id.path.path, id.path.lvl0, id.path.lvl1, id.path.lvl2,
+		)
+	default:
+		dir := filepath.ToSlash(filepath.Dir(fname))
+		if !strings.HasSuffix("/"+dir, "/"+p.path) {
+			add(SeverityError, RulePath, "path %q disagrees with file location %q", p.path, dir)
+		}
+	}
+
+	if !id.runs.isSpecified() {
+		add(SeverityError, RuleRunRange, "invalid run range %v", id.runs)
+	}
+
+	runs, vers, subvers, err := ParseFilename(fname)
+	if err != nil {
+		add(SeverityError, RuleFilename, "%v", errors.Cause(err))
+	} else {
+		if !runs.Equal(id.runs) {
+			add(SeverityError, RuleRunRange, "file name run range [%d, %d] disagrees with entry run range [%d, %d]",
+				runs.First, runs.Last, id.runs.First, id.runs.Last,
+			)
+		}
+		if vers != id.vers || subvers != id.subvers {
+			add(SeverityError, RuleVersion, "file name version v%d_s%d disagrees with entry version v%d_s%d",
+				vers, subvers, id.vers, id.subvers,
+			)
+		}
+	}
+
+	switch {
+	case entry.obj == nil:
+		add(SeverityError, RuleObject, "entry holds no object")
+	case entry.meta == nil:
+		add(SeverityWarning, RuleMetaData, "entry has no metadata")
+	case entry.meta.class == "":
+		add(SeverityWarning, RuleClass, "metadata does not record the object class (%s)", entry.obj.Class())
+	case entry.meta.class != entry.obj.Class():
+		add(SeverityError, RuleClass, "metadata object class %q disagrees with object class %q",
+			entry.meta.class, entry.obj.Class(),
+		)
+	}
+
+	return fs
+}
+
+// ValidateTree validates all the ".root" files under dir.
+// Findings are sorted by file name.
+func ValidateTree(dir string) ([]Finding, error) {
+	var fs []Finding
+	err := filepath.Walk(dir, func(fname string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(fname) != ".root" {
+			return nil
+		}
+		fs = append(fs, Validate(fname)...)
+		return nil
+	})
+	if err != nil {
+		return fs, errors.Wrapf(err, "ocdb: could not walk %q", dir)
+	}
+
+	sort.SliceStable(fs, func(i, j int) bool { return fs[i].File < fs[j].File })
+	return fs, nil
+}
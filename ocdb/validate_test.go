@@ -0,0 +1,238 @@
+// Copyright 2019 The Alice-Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ocdb
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-hep.org/x/hep/groot/rbase"
+	"go-hep.org/x/hep/groot/root"
+)
+
+func TestSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		sev  Severity
+		want string
+	}{
+		{0, "Severity(0)"},
+		{SeverityWarning, "warning"},
+		{SeverityError, "error"},
+	} {
+		if got := tc.sev.String(); got != tc.want {
+			t.Fatalf("invalid severity name: got=%q, want=%q", got, tc.want)
+		}
+	}
+}
+
+func TestValidateEntry(t *testing.T) {
+	type rule struct {
+		sev  Severity
+		rule string
+	}
+
+	const fname = "OCDB/MUON/Calib/Pedestals/Run1_10_v2_s1.root"
+	var (
+		ped  = NewPath("MUON", "Calib", "Pedestals")
+		runs = NewRunRange(1, 10)
+		obj  = rbase.NewObjString("payload")
+	)
+	meta := func(class string) *MetaData {
+		m := NewMetaData("tester", 0, "", "")
+		m.class = class
+		return m
+	}
+	entry := func(p Path, runs RunRange, vers, subvers int32, obj root.Object, meta *MetaData) *Entry {
+		return &Entry{
+			base: *rbase.NewObject(),
+			obj:  obj,
+			id:   NewID(p, runs, vers, subvers),
+			meta: meta,
+		}
+	}
+	badLevels := ped
+	badLevels.lvl2 = "Gains"
+
+	for _, tc := range []struct {
+		name  string
+		fname string
+		entry *Entry
+		want  []rule
+	}{
+		{
+			name:  "valid",
+			fname: fname,
+			entry: entry(ped, runs, 2, 1, obj, meta("TObjString")),
+		},
+		{
+			name:  "valid-relative",
+			fname: "MUON/Calib/Pedestals/Run1_10_v2_s1.root",
+			entry: entry(ped, runs, 2, 1, obj, meta("TObjString")),
+		},
+		{
+			name:  "filename",
+			fname: "OCDB/MUON/Calib/Pedestals/pedestals.root",
+			entry: entry(ped, runs, 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RuleFilename}},
+		},
+		{
+			name:  "invalid-path",
+			fname: fname,
+			entry: entry(NewPath("MUON", "Calib", "Ped estals"), runs, 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RulePath}},
+		},
+		{
+			name:  "wildcard-path",
+			fname: fname,
+			entry: entry(NewPath("MUON", "*", "Pedestals"), runs, 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RulePath}},
+		},
+		{
+			name:  "path-levels",
+			fname: fname,
+			entry: entry(badLevels, runs, 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RulePath}},
+		},
+		{
+			name:  "path-location",
+			fname: "OCDB/MUON/Calib/Gains/Run1_10_v2_s1.root",
+			entry: entry(ped, runs, 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RulePath}},
+		},
+		{
+			name:  "path-suffix",
+			fname: "OCDB/XMUON/Calib/Pedestals/Run1_10_v2_s1.root",
+			entry: entry(ped, runs, 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RulePath}},
+		},
+		{
+			name:  "invalid-runs",
+			fname: "OCDB/MUON/Calib/Pedestals/Run10_1_v2_s1.root",
+			entry: entry(ped, NewRunRange(10, 1), 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RuleRunRange}},
+		},
+		{
+			name:  "any-runs",
+			fname: "OCDB/MUON/Calib/Pedestals/Run-1_-1_v2_s1.root",
+			entry: entry(ped, NewRunRange(-1, -1), 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RuleRunRange}, {SeverityError, RuleFilename}},
+		},
+		{
+			name:  "filename-runs",
+			fname: fname,
+			entry: entry(ped, NewRunRange(1, 11), 2, 1, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RuleRunRange}},
+		},
+		{
+			name:  "filename-version",
+			fname: fname,
+			entry: entry(ped, runs, 2, 0, obj, meta("TObjString")),
+			want:  []rule{{SeverityError, RuleVersion}},
+		},
+		{
+			name:  "object",
+			fname: fname,
+			entry: entry(ped, runs, 2, 1, nil, meta("TObjString")),
+			want:  []rule{{SeverityError, RuleObject}},
+		},
+		{
+			name:  "metadata",
+			fname: fname,
+			entry: entry(ped, runs, 2, 1, obj, nil),
+			want:  []rule{{SeverityWarning, RuleMetaData}},
+		},
+		{
+			name:  "no-class",
+			fname: fname,
+			entry: entry(ped, runs, 2, 1, obj, meta("")),
+			want:  []rule{{SeverityWarning, RuleClass}},
+		},
+		{
+			name:  "class",
+			fname: fname,
+			entry: entry(ped, runs, 2, 1, obj, meta("AliMUON2DMap")),
+			want:  []rule{{SeverityError, RuleClass}},
+		},
+		{
+			name:  "several",
+			fname: "OCDB/MUON/Calib/Gains/Run1_10_v1_s0.root",
+			entry: entry(ped, runs, 2, 1, nil, nil),
+			want: []rule{
+				{SeverityError, RulePath},
+				{SeverityError, RuleVersion},
+				{SeverityError, RuleObject},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []rule
+			for _, f := range ValidateEntry(tc.fname, tc.entry) {
+				if f.File != tc.fname {
+					t.Fatalf("invalid finding file: got=%q, want=%q", f.File, tc.fname)
+				}
+				if f.Msg == "" {
+					t.Fatalf("finding without message: %v", f)
+				}
+				got = append(got, rule{f.Severity, f.Rule})
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("invalid findings:\ngot= %v\nwant=%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateTree(t *testing.T) {
+	ped := NewPath("MUON", "Calib", "Pedestals")
+	db, cleanup := newTestLocal(t, []testPut{
+		{NewID(ped, NewRunRange(1, 10), -1, -1), "v1"},
+		{NewID(ped, NewRunRange(11, 20), -1, -1), "v1"},
+	})
+	defer cleanup()
+
+	// a file that is not an OCDB entry, and a copy of a valid entry
+	// stored under another name.
+	bad := filepath.Join(db.Dir(), "MUON", "Calib", "Pedestals", "Run21_30_v1_s0.root")
+	err := ioutil.WriteFile(bad, []byte("not a ROOT file"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadFile(filepath.Join(db.Dir(), "MUON", "Calib", "Pedestals", "Run1_10_v1_s0.root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := filepath.Join(db.Dir(), "MUON", "Calib", "Pedestals", "Run1_10_v2_s0.root")
+	err = ioutil.WriteFile(moved, raw, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// not a ROOT file: ignored.
+	err = ioutil.WriteFile(filepath.Join(db.Dir(), "README"), []byte("OCDB"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := ValidateTree(db.Dir())
+	if err != nil {
+		t.Fatalf("could not validate tree: %+v", err)
+	}
+	var got []string
+	for _, f := range fs {
+		got = append(got, filepath.Base(f.File)+":"+f.Rule+":"+f.Severity.String())
+	}
+	want := []string{
+		"Run1_10_v2_s0.root:version:error",
+		"Run21_30_v1_s0.root:read:error",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid findings:\ngot= %q\nwant=%q", got, want)
+	}
+
+	if _, err := ValidateTree(filepath.Join(db.Dir(), "missing")); err == nil {
+		t.Fatalf("expected an error for a missing directory")
+	}
+}